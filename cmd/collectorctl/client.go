@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/internal/core/services"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// clientFlags are the connection and output flags shared by the commands talking to the server.
+// The agent environment variables (ADDRESS, KEY, API_KEY, ...) take precedence over them as in the agent.
+type clientFlags struct {
+	fc     config.FlagContainer
+	output string
+}
+
+func newClientFlagSet(name string) (*flag.FlagSet, *clientFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	cf := &clientFlags{fc: config.FlagContainer{AppType: config.AppTypeAgent}}
+
+	fs.StringVar(&cf.fc.Address, "a", "localhost:8080", "server address")
+	fs.StringVar(&cf.fc.HashKey, "k", "", "hash key signing the requests")
+	fs.StringVar(&cf.fc.HashKeyID, "key_id", "", "hash key id")
+	fs.StringVar(&cf.fc.HashKeys, "keys", "", "comma-separated id:key pairs response signatures are verified with")
+	fs.StringVar(&cf.fc.APIKey, "api_key", "", "api key")
+	fs.StringVar(&cf.fc.Tenant, "tenant", "", "tenant")
+	fs.StringVar(&cf.fc.CryptoKey, "crypto_key", "", "public key file the request bodies are encrypted for")
+	fs.BoolVar(&cf.fc.TLS, "tls", false, "connect to the server over tls")
+	fs.StringVar(&cf.fc.TLSCA, "tls_ca", "", "CA file the server certificate is pinned to")
+	fs.StringVar(&cf.fc.TLSCert, "tls_cert", "", "client certificate file")
+	fs.StringVar(&cf.fc.TLSKey, "tls_key", "", "client private key file")
+	fs.StringVar(&cf.fc.TLSMinVersion, "tls_min_version", "1.2", "minimal tls version: 1.2 or 1.3")
+	fs.StringVar(&cf.fc.Encoding, "encoding", domain.EncodingJSON, "body encoding: json, protobuf or msgpack")
+	fs.StringVar(&cf.fc.Compress, "compress", "", "body compression: gzip, zstd or br, none when empty")
+	fs.StringVar(&cf.output, "o", outputTable, "output format: table or json")
+
+	return fs, cf
+}
+
+func (cf *clientFlags) newClient() (*services.Client, error) {
+	if cf.output != outputTable && cf.output != outputJSON {
+		return nil, fmt.Errorf("unknown output format: %s", cf.output)
+	}
+
+	cf.fc.Transport = config.TransportHTTP
+
+	ec := new(config.EnvContainer)
+	ec.Parse()
+
+	conf, confErr := config.NewAgentConfig(&cf.fc, ec)
+	if confErr != nil {
+		return nil, fmt.Errorf("client config error: %w", confErr)
+	}
+
+	return services.NewClient(conf, ""), nil
+}
+
+func printForms(out io.Writer, output string, forms []domain.MetricForm) error {
+	if output == outputJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(forms); err != nil {
+			return fmt.Errorf("encode output error: %w", err)
+		}
+
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NAME\tTYPE\tVALUE\tUNIT\tHELP")
+
+	for _, form := range forms {
+		var meta domain.MetricMeta
+		if form.Meta != nil {
+			meta = *form.Meta
+		}
+
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", form.ID, form.MType, formValue(&form), meta.Unit, meta.Help)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("write output error: %w", err)
+	}
+
+	return nil
+}
+
+func formValue(form *domain.MetricForm) string {
+	switch {
+	case form.Delta != nil:
+		return strconv.FormatInt(*form.Delta, 10)
+	case form.Value != nil:
+		return strconv.FormatFloat(*form.Value, 'g', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// parseArgs parses the flags and checks the number of positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string, usage string, minArgs int, maxArgs int) ([]string, error) {
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(fs.Output(), "usage: collectorctl %s %s\n", fs.Name(), usage)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() < minArgs || fs.NArg() > maxArgs {
+		fs.Usage()
+
+		return nil, errors.New("wrong number of arguments")
+	}
+
+	return fs.Args(), nil
+}
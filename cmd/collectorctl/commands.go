@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"collector/internal/core/domain"
+	"collector/internal/core/services"
+)
+
+const defaultPushBatchSize = 500
+
+func runGet(ctx context.Context, args []string, stdout io.Writer) error {
+	fs, cf := newClientFlagSet("get")
+
+	posArgs, parseErr := parseArgs(fs, args, "[flags] <gauge|counter> <name>", 2, 2)
+	if parseErr != nil {
+		return parseErr
+	}
+
+	mType := domain.MetricType(posArgs[0])
+	if !mType.IsValid() {
+		return fmt.Errorf("unknown metric type: %s", posArgs[0])
+	}
+
+	client, clientErr := cf.newClient()
+	if clientErr != nil {
+		return clientErr
+	}
+
+	form, valueErr := client.Value(ctx, mType, posArgs[1])
+	if valueErr != nil {
+		return fmt.Errorf("get metric error: %w", valueErr)
+	}
+
+	return printForms(stdout, cf.output, []domain.MetricForm{*form})
+}
+
+func runSet(ctx context.Context, args []string, stdout io.Writer) error {
+	fs, cf := newClientFlagSet("set")
+
+	posArgs, parseErr := parseArgs(fs, args, "[flags] <name> <value>", 2, 2)
+	if parseErr != nil {
+		return parseErr
+	}
+
+	value, convErr := strconv.ParseFloat(posArgs[1], 64)
+	if convErr != nil {
+		return fmt.Errorf("invalid gauge value %q: %w", posArgs[1], convErr)
+	}
+
+	return sendForm(ctx, cf, stdout, &domain.MetricForm{ID: posArgs[0], MType: domain.MetricTypeGauge, Value: &value})
+}
+
+func runInc(ctx context.Context, args []string, stdout io.Writer) error {
+	fs, cf := newClientFlagSet("inc")
+
+	posArgs, parseErr := parseArgs(fs, args, "[flags] <name> [delta]", 1, 2)
+	if parseErr != nil {
+		return parseErr
+	}
+
+	delta := int64(1)
+
+	if len(posArgs) == 2 {
+		var convErr error
+
+		delta, convErr = strconv.ParseInt(posArgs[1], 10, 64)
+		if convErr != nil {
+			return fmt.Errorf("invalid counter delta %q: %w", posArgs[1], convErr)
+		}
+	}
+
+	return sendForm(ctx, cf, stdout, &domain.MetricForm{ID: posArgs[0], MType: domain.MetricTypeCounter, Delta: &delta})
+}
+
+func sendForm(ctx context.Context, cf *clientFlags, stdout io.Writer, form *domain.MetricForm) error {
+	client, clientErr := cf.newClient()
+	if clientErr != nil {
+		return clientErr
+	}
+
+	stored, updateErr := client.Update(ctx, form)
+	if updateErr != nil {
+		return fmt.Errorf("update metric error: %w", updateErr)
+	}
+
+	return printForms(stdout, cf.output, []domain.MetricForm{*stored})
+}
+
+func runList(ctx context.Context, args []string, stdout io.Writer) error {
+	fs, cf := newClientFlagSet("list")
+	query := filterFlags(fs)
+	limit := fs.Int("limit", 0, "max number of metrics to show, all when 0")
+
+	if _, parseErr := parseArgs(fs, args, "[flags]", 0, 0); parseErr != nil {
+		return parseErr
+	}
+
+	client, clientErr := cf.newClient()
+	if clientErr != nil {
+		return clientErr
+	}
+
+	var forms []domain.MetricForm
+
+	values := query()
+
+	for {
+		var page domain.MetricsPage
+
+		body, callErr := client.Call(ctx, http.MethodGet, "/api/v1/metrics?"+values.Encode(), nil)
+		if callErr != nil {
+			return fmt.Errorf("list metrics error: %w", callErr)
+		}
+
+		if decodeErr := json.Unmarshal(body, &page); decodeErr != nil {
+			return fmt.Errorf("decode metrics page error: %w", decodeErr)
+		}
+
+		forms = append(forms, page.Metrics...)
+
+		if *limit > 0 && len(forms) >= *limit {
+			forms = forms[:*limit]
+
+			break
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+
+		values.Set("cursor", page.NextCursor)
+	}
+
+	return printForms(stdout, cf.output, forms)
+}
+
+// runWatch prints the metric updates from the server stream until interrupted.
+func runWatch(ctx context.Context, args []string, stdout io.Writer) error {
+	fs, cf := newClientFlagSet("watch")
+	query := filterFlags(fs)
+
+	if _, parseErr := parseArgs(fs, args, "[flags]", 0, 0); parseErr != nil {
+		return parseErr
+	}
+
+	client, clientErr := cf.newClient()
+	if clientErr != nil {
+		return clientErr
+	}
+
+	req, reqErr := client.NewRequest(ctx, http.MethodGet, "/api/v1/stream?"+query().Encode(), nil)
+	if reqErr != nil {
+		return fmt.Errorf("build request error: %w", reqErr)
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Accept-Encoding", "identity")
+
+	resp, respErr := client.Do(req)
+	if respErr != nil {
+		return respErr
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+
+		return &services.StatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+
+	for scanner.Scan() {
+		data, isData := strings.CutPrefix(scanner.Text(), "data: ")
+		if !isData {
+			continue
+		}
+
+		form := new(domain.MetricForm)
+
+		if decodeErr := domain.JSONCodec.UnmarshalForm([]byte(data), form); decodeErr != nil {
+			return fmt.Errorf("decode event error: %w", decodeErr)
+		}
+
+		if printErr := printEvent(stdout, cf.output, form); printErr != nil {
+			return printErr
+		}
+	}
+
+	if scanErr := scanner.Err(); scanErr != nil && ctx.Err() == nil {
+		return fmt.Errorf("read stream error: %w", scanErr)
+	}
+
+	return nil
+}
+
+// printEvent writes the update as a single line, so the output can be piped while streaming.
+func printEvent(out io.Writer, output string, form *domain.MetricForm) error {
+	if output == outputJSON {
+		if err := json.NewEncoder(out).Encode(form); err != nil {
+			return fmt.Errorf("encode output error: %w", err)
+		}
+
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(out, "%s %s %s\n", form.MType, form.ID, formValue(form)); err != nil {
+		return fmt.Errorf("write output error: %w", err)
+	}
+
+	return nil
+}
+
+// runPushFile sends the JSON array of metrics from the file, or stdin for "-", in batches.
+func runPushFile(ctx context.Context, args []string, stdout io.Writer) error {
+	fs, cf := newClientFlagSet("push-file")
+	batchSize := fs.Int("batch", defaultPushBatchSize, "number of metrics sent per request")
+
+	posArgs, parseErr := parseArgs(fs, args, "[flags] <file|->", 1, 1)
+	if parseErr != nil {
+		return parseErr
+	}
+
+	if *batchSize <= 0 {
+		return errors.New("batch size must be positive")
+	}
+
+	data, readErr := readInput(posArgs[0])
+	if readErr != nil {
+		return readErr
+	}
+
+	forms, decodeErr := domain.JSONCodec.UnmarshalForms(data)
+	if decodeErr != nil {
+		return fmt.Errorf("decode metrics error: %w", decodeErr)
+	}
+
+	for i := range forms {
+		if validateErr := forms[i].Validate(); validateErr != nil {
+			return fmt.Errorf("metric #%d %q: %w", i, forms[i].ID, validateErr)
+		}
+	}
+
+	client, clientErr := cf.newClient()
+	if clientErr != nil {
+		return clientErr
+	}
+
+	for start := 0; start < len(forms); start += *batchSize {
+		batch := forms[start:min(start+*batchSize, len(forms))]
+
+		if sendErr := client.UpdateBatch(ctx, batch); sendErr != nil {
+			return fmt.Errorf("push metrics %d-%d error: %w", start, start+len(batch)-1, sendErr)
+		}
+	}
+
+	_, _ = fmt.Fprintf(stdout, "pushed %d metrics\n", len(forms))
+
+	return nil
+}
+
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("read stdin error: %w", err)
+		}
+
+		return data, nil
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("read file error: %w", err)
+	}
+
+	return data, nil
+}
+
+// filterFlags registers the metric filter flags, the returned func builds the query of them.
+func filterFlags(fs *flag.FlagSet) func() url.Values {
+	var mType, match, regex string
+
+	fs.StringVar(&mType, "type", "", "metric type: gauge or counter")
+	fs.StringVar(&match, "match", "", "glob pattern of the metric names")
+	fs.StringVar(&regex, "regex", "", "regular expression of the metric names")
+
+	return func() url.Values {
+		values := url.Values{}
+
+		for key, value := range map[string]string{"type": mType, "match": match, "regex": regex} {
+			if value != "" {
+				values.Set(key, value)
+			}
+		}
+
+		return values
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const usage = `usage: collectorctl <command> [flags]
+
+commands:
+  get        show a metric value
+  set        set a gauge value
+  inc        add to a counter, by 1 when the delta is omitted
+  list       list the metrics matching the filter
+  watch      print the metric updates as they are accepted
+  push-file  send the metrics from a JSON file
+  migrate    copy metrics from one store to another
+
+run "collectorctl <command> -h" for the command flags
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = fmt.Fprint(stderr, usage)
+
+		return 2
+	}
+
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	slog.SetDefault(logger)
+
+	var err error
+
+	switch args[0] {
+	case "get":
+		err = runGet(ctx, args[1:], stdout)
+	case "set":
+		err = runSet(ctx, args[1:], stdout)
+	case "inc":
+		err = runInc(ctx, args[1:], stdout)
+	case "list":
+		err = runList(ctx, args[1:], stdout)
+	case "watch":
+		err = runWatch(ctx, args[1:], stdout)
+	case "push-file":
+		err = runPushFile(ctx, args[1:], stdout)
+	case "migrate":
+		err = runMigrate(ctx, args[1:], logger, stdout)
+	case "help", "-h", "--help":
+		_, _ = fmt.Fprint(stdout, usage)
+
+		return 0
+	default:
+		_, _ = fmt.Fprintf(stderr, "unknown command %q\n%s", args[0], usage)
+
+		return 2
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "collectorctl %s: %v\n", args[0], err)
+
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"collector/internal/core/domain"
+)
+
+func TestRun(t *testing.T) {
+	value := 1.0
+	pages := map[string]domain.MetricsPage{
+		"":   {Metrics: []domain.MetricForm{{ID: "Alloc", MType: domain.MetricTypeGauge, Value: &value}}, NextCursor: "c1"},
+		"c1": {Metrics: []domain.MetricForm{{ID: "Frees", MType: domain.MetricTypeGauge, Value: &value}}},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/metrics", func(writer http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(writer).Encode(pages[req.URL.Query().Get("cursor")])
+	})
+	mux.HandleFunc("POST /value/", func(writer http.ResponseWriter, _ *http.Request) {
+		http.Error(writer, "metric not found", http.StatusNotFound)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	address := strings.TrimPrefix(srv.URL, "http://")
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantOut    []string
+		wantNotOut []string
+		wantErr    string
+	}{
+		{name: "list follows the pages", args: []string{"list", "-a", address}, wantOut: []string{"Alloc", "Frees"}},
+		{
+			name:       "list stops at the limit",
+			args:       []string{"list", "-a", address, "-limit", "1"},
+			wantOut:    []string{"Alloc"},
+			wantNotOut: []string{"Frees"},
+		},
+		{
+			name:     "error status",
+			args:     []string{"get", "-a", address, "gauge", "Missing"},
+			wantCode: 1,
+			wantErr:  "collectorctl get: get metric error: Not Found: metric not found",
+		},
+		{name: "unknown command", args: []string{"drop"}, wantCode: 2, wantErr: `unknown command "drop"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			if code := run(context.Background(), tt.args, &stdout, &stderr); code != tt.wantCode {
+				t.Fatalf("run() = %d, want %d, stderr: %s", code, tt.wantCode, stderr.String())
+			}
+
+			for _, want := range tt.wantOut {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("stdout = %q, want %q in it", stdout.String(), want)
+				}
+			}
+
+			for _, notWant := range tt.wantNotOut {
+				if strings.Contains(stdout.String(), notWant) {
+					t.Errorf("stdout = %q, want no %q in it", stdout.String(), notWant)
+				}
+			}
+
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("stderr = %q, want %q in it", stderr.String(), tt.wantErr)
+			}
+		})
+	}
+}
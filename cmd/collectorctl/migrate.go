@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"collector/internal/adapters/store"
+	"collector/internal/core/domain"
+	"collector/internal/core/services"
+)
+
+func runMigrate(ctx context.Context, args []string, logger *slog.Logger, out io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	from := fs.String("from", "", "source store: file:<path>, postgres://<dsn> or memory:")
+	to := fs.String("to", "", "target store: file:<path>, postgres://<dsn> or memory:")
+	dryRun := fs.Bool("dry-run", false, "report the changes without writing the target store")
+	allowEmpty := fs.Bool("allow-empty", false, "let a source without series replace the target tenants")
+	diff := fs.Bool("diff", false, "print every changed series, not only the per tenant summary")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *from == "" || *to == "" {
+		return errors.New("both --from and --to are required")
+	}
+
+	if *from == *to {
+		return errors.New("--from and --to point to the same store")
+	}
+
+	source, sourceErr := store.Open(ctx, logger, *from, domain.NewTenants())
+	if sourceErr != nil {
+		return fmt.Errorf("open source store error: %w", sourceErr)
+	}
+	defer closeStore(ctx, logger, source)
+
+	target, targetErr := store.Open(ctx, logger, *to, domain.NewTenants())
+	if targetErr != nil {
+		return fmt.Errorf("open target store error: %w", targetErr)
+	}
+	defer closeStore(ctx, logger, target)
+
+	diffs, migrateErr := services.Migrate(
+		ctx,
+		source,
+		target,
+		services.MigrateOptions{DryRun: *dryRun, AllowEmpty: *allowEmpty},
+	)
+	if migrateErr != nil {
+		return migrateErr
+	}
+
+	printDiffs(out, diffs, *diff)
+
+	if *dryRun {
+		_, _ = fmt.Fprintln(out, "dry run, nothing written")
+
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(out, "migrated %d tenant(s) to %s\n", len(diffs), target.GetStoreType())
+
+	return nil
+}
+
+func printDiffs(out io.Writer, diffs []services.TenantDiff, verbose bool) {
+	for _, tenantDiff := range diffs {
+		counts := make(map[domain.ChangeOp]int)
+		for _, change := range tenantDiff.Changes {
+			counts[change.Op]++
+		}
+
+		_, _ = fmt.Fprintf(
+			out,
+			"tenant %s: %d added, %d updated, %d removed\n",
+			tenantDiff.Tenant,
+			counts[domain.ChangeAdd],
+			counts[domain.ChangeUpdate],
+			counts[domain.ChangeRemove],
+		)
+
+		if !verbose {
+			continue
+		}
+
+		for _, change := range tenantDiff.Changes {
+			_, _ = fmt.Fprintf(out, "  %s\n", change)
+		}
+	}
+}
+
+func closeStore(ctx context.Context, logger *slog.Logger, st store.Store) {
+	if err := st.Close(); err != nil {
+		logger.WarnContext(ctx, "close store error", slog.Any("error", err))
+	}
+}
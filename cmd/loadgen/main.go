@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/netip"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/internal/core/services"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+
+	// maxAgents is the size of the benchmarking range the agent addresses are taken from.
+	maxAgents = 1 << 17
+)
+
+// agentIPBase starts the 198.18.0.0/15 benchmarking range, every agent reports from its own address of it.
+var agentIPBase = netip.MustParseAddr("198.18.0.0")
+
+type options struct {
+	fc       config.FlagContainer
+	agents   int
+	metrics  int
+	rate     float64
+	duration time.Duration
+	progress time.Duration
+	batch    bool
+	unique   bool
+	output   string
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	slog.SetDefault(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	opts, parseErr := parseOptions(args, stderr)
+	if errors.Is(parseErr, flag.ErrHelp) {
+		return 0
+	}
+
+	if parseErr != nil {
+		_, _ = fmt.Fprintf(stderr, "loadgen: %v\n", parseErr)
+
+		return 2
+	}
+
+	ec := new(config.EnvContainer)
+	ec.Parse()
+
+	conf, confErr := config.NewAgentConfig(&opts.fc, ec)
+	if confErr != nil {
+		_, _ = fmt.Fprintf(stderr, "loadgen: config error: %v\n", confErr)
+
+		return 2
+	}
+
+	rep := generate(ctx, conf, opts, stderr)
+
+	if printErr := rep.print(stdout, opts.output); printErr != nil {
+		_, _ = fmt.Fprintf(stderr, "loadgen: %v\n", printErr)
+
+		return 1
+	}
+
+	if rep.Requests == 0 || rep.Errors == rep.Requests {
+		return 1
+	}
+
+	return 0
+}
+
+func parseOptions(args []string, stderr io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	opts := &options{fc: config.FlagContainer{AppType: config.AppTypeAgent, Transport: config.TransportHTTP}}
+
+	fs.IntVar(
+		&opts.agents,
+		"n",
+		10,
+		"number of simulated agents, each sends its own X-Real-IP from 198.18.0.0/15, "+
+			"the server accounts them apart when it trusts this host with -trusted_proxy",
+	)
+	fs.IntVar(&opts.metrics, "m", 30, "number of metrics every agent reports")
+	fs.Float64Var(&opts.rate, "rate", 1, "reports per second of every agent")
+	fs.DurationVar(&opts.duration, "d", 30*time.Second, "test duration, until interrupted when 0")
+	fs.DurationVar(&opts.progress, "progress", 5*time.Second, "progress report interval, disabled when 0")
+	fs.BoolVar(&opts.batch, "batch", false, "report the metrics of an agent in a single /updates/ request")
+	fs.BoolVar(&opts.unique, "unique", false, "give every agent its own series instead of sharing the names")
+	fs.StringVar(&opts.output, "o", outputText, "output format: text or json")
+
+	fs.StringVar(&opts.fc.Address, "a", "localhost:8080", "server address")
+	fs.IntVar(&opts.fc.RateLimit, "l", 5, "concurrent requests of every agent")
+	fs.StringVar(&opts.fc.HashKey, "k", "", "hash key")
+	fs.StringVar(&opts.fc.HashKeyID, "key_id", "", "hash key id")
+	fs.StringVar(&opts.fc.HashKeys, "keys", "", "comma-separated id:key pairs response signatures are verified with")
+	fs.StringVar(&opts.fc.APIKey, "api_key", "", "api key, all the agents share it and are accounted as one")
+	fs.StringVar(&opts.fc.Tenant, "tenant", "", "tenant to report metrics to")
+	fs.StringVar(&opts.fc.CryptoKey, "crypto_key", "", "public key file the request bodies are encrypted for")
+	fs.BoolVar(&opts.fc.TLS, "tls", false, "connect to the server over tls")
+	fs.StringVar(&opts.fc.TLSCA, "tls_ca", "", "CA file the server certificate is pinned to")
+	fs.StringVar(&opts.fc.TLSCert, "tls_cert", "", "tls certificate file")
+	fs.StringVar(&opts.fc.TLSKey, "tls_key", "", "tls private key file")
+	fs.StringVar(&opts.fc.TLSMinVersion, "tls_min_version", "1.2", "minimal tls version: 1.2 or 1.3")
+	fs.StringVar(&opts.fc.Encoding, "encoding", domain.EncodingJSON, "body encoding: json, protobuf or msgpack")
+	fs.StringVar(&opts.fc.Compress, "compress", "", "body compression: zstd, br or gzip, none when empty")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case fs.NArg() > 0:
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	case opts.agents <= 0 || opts.metrics <= 0 || opts.fc.RateLimit <= 0:
+		return nil, errors.New("agents, metrics and concurrency must be positive")
+	case opts.agents > maxAgents:
+		return nil, fmt.Errorf("at most %d agents are supported", maxAgents)
+	case opts.rate <= 0:
+		return nil, errors.New("rate must be positive")
+	case opts.output != outputText && opts.output != outputJSON:
+		return nil, fmt.Errorf("unknown output format: %s", opts.output)
+	}
+
+	return opts, nil
+}
+
+// generate runs the agents until the duration elapses or the context is done.
+func generate(ctx context.Context, conf *config.AgentConfig, opts *options, stderr io.Writer) *report {
+	if opts.duration > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	rec := newRecorder()
+	interval := time.Duration(float64(time.Second) / opts.rate)
+
+	// Every simulated agent keeps its workers' connections open as a real agent does.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = conf.GetTLSConfig()
+	transport.MaxIdleConnsPerHost = opts.agents * conf.RateLimit
+	defer transport.CloseIdleConnections()
+
+	var wg sync.WaitGroup
+
+	for id := range opts.agents {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// Spread the agents over the interval instead of reporting all at once.
+			delay := interval * time.Duration(id) / time.Duration(opts.agents)
+
+			runAgent(
+				ctx,
+				services.NewClientWithTransport(conf, agentIP(id).String(), transport),
+				newAgentForms(id, opts),
+				conf.RateLimit,
+				opts.batch,
+				delay,
+				interval,
+				rec,
+			)
+		}()
+	}
+
+	if opts.progress > 0 {
+		go printProgress(ctx, rec, opts.progress, stderr)
+	}
+
+	wg.Wait()
+
+	return rec.report(opts.agents)
+}
+
+func runAgent(
+	ctx context.Context,
+	client *services.Client,
+	forms []domain.MetricForm,
+	workers int,
+	batch bool,
+	delay time.Duration,
+	interval time.Duration,
+	rec *recorder,
+) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(delay):
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		refreshForms(forms)
+
+		if batch {
+			sendBatch(ctx, client, forms, rec)
+		} else {
+			sendSingle(ctx, client, forms, workers, rec)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func sendBatch(ctx context.Context, client *services.Client, forms []domain.MetricForm, rec *recorder) {
+	start := time.Now()
+	err := client.UpdateBatch(ctx, forms)
+
+	rec.observe(ctx, time.Since(start), len(forms), err)
+}
+
+// sendSingle reports every metric in its own request by the pool of workers like the agent does.
+func sendSingle(ctx context.Context, client *services.Client, forms []domain.MetricForm, workers int, rec *recorder) {
+	jobs := make(chan *domain.MetricForm, len(forms))
+	for i := range forms {
+		jobs <- &forms[i]
+	}
+
+	close(jobs)
+
+	var wg sync.WaitGroup
+
+	for range min(workers, len(forms)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for form := range jobs {
+				start := time.Now()
+				err := client.Send(ctx, form)
+
+				rec.observe(ctx, time.Since(start), 1, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// agentIP returns the address the agent reports from, so the server rate limits and budgets every agent apart.
+func agentIP(id int) netip.Addr {
+	addr := agentIPBase.As4()
+	binary.BigEndian.PutUint32(addr[:], binary.BigEndian.Uint32(addr[:])+uint32(id))
+
+	return netip.AddrFrom4(addr)
+}
+
+// newAgentForms returns the metrics of the agent, half of them gauges and half counters.
+func newAgentForms(id int, opts *options) []domain.MetricForm {
+	prefix := "Load"
+	if opts.unique {
+		prefix = fmt.Sprintf("Load%d", id)
+	}
+
+	forms := make([]domain.MetricForm, opts.metrics)
+
+	for i := range forms {
+		if i%2 == 0 {
+			forms[i] = domain.MetricForm{ID: fmt.Sprintf("%sGauge%d", prefix, i/2), MType: domain.MetricTypeGauge}
+		} else {
+			forms[i] = domain.MetricForm{ID: fmt.Sprintf("%sCounter%d", prefix, i/2), MType: domain.MetricTypeCounter}
+		}
+	}
+
+	return forms
+}
+
+func refreshForms(forms []domain.MetricForm) {
+	for i := range forms {
+		if forms[i].IsGaugeType() {
+			value := rand.Float64() * 1000
+			forms[i].Value = &value
+		} else {
+			delta := int64(1)
+			forms[i].Delta = &delta
+		}
+	}
+}
+
+func printProgress(ctx context.Context, rec *recorder, interval time.Duration, out io.Writer) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			requests, errs, elapsed := rec.progress()
+			_, _ = fmt.Fprintf(
+				out,
+				"%6.1fs  requests %d  %.1f req/s  errors %d\n",
+				elapsed.Seconds(),
+				requests,
+				float64(requests)/elapsed.Seconds(),
+				errs,
+			)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"collector/internal/core/services"
+)
+
+// latencySamples bounds the latencies kept for the percentiles, so an endless run uses fixed memory.
+const latencySamples = 100_000
+
+// recorder collects the request latencies and errors of all the agents.
+// The latencies are a uniform reservoir sample of all the requests, the maximum is exact.
+type recorder struct {
+	mx         sync.Mutex
+	start      time.Time
+	latencies  []time.Duration
+	requests   int
+	maxLatency time.Duration
+	metrics    int
+	errors     map[string]int
+	samples    map[string]string
+}
+
+type latencyReport struct {
+	P50 float64 `json:"p50_ms"`
+	P95 float64 `json:"p95_ms"`
+	P99 float64 `json:"p99_ms"`
+	Max float64 `json:"max_ms"`
+}
+
+type report struct {
+	Duration       float64           `json:"duration_seconds"`
+	Agents         int               `json:"agents"`
+	Requests       int               `json:"requests"`
+	Metrics        int               `json:"metrics"`
+	Errors         int               `json:"errors"`
+	ErrorRate      float64           `json:"error_rate"`
+	RequestsPerSec float64           `json:"requests_per_second"`
+	MetricsPerSec  float64           `json:"metrics_per_second"`
+	Latency        latencyReport     `json:"latency"`
+	ErrorKinds     map[string]int    `json:"error_kinds,omitempty"`
+	ErrorSamples   map[string]string `json:"error_samples,omitempty"`
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		start:   time.Now(),
+		errors:  make(map[string]int),
+		samples: make(map[string]string),
+	}
+}
+
+// observe records the request, the ones interrupted by the end of the test are not counted.
+func (r *recorder) observe(ctx context.Context, latency time.Duration, metrics int, err error) {
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.requests++
+	r.maxLatency = max(r.maxLatency, latency)
+
+	if len(r.latencies) < latencySamples {
+		r.latencies = append(r.latencies, latency)
+	} else if i := rand.IntN(r.requests); i < latencySamples {
+		r.latencies[i] = latency
+	}
+
+	if err == nil {
+		r.metrics += metrics
+
+		return
+	}
+
+	kind := errorKind(err)
+	r.errors[kind]++
+
+	if _, ok := r.samples[kind]; !ok {
+		r.samples[kind] = err.Error()
+	}
+}
+
+func (r *recorder) progress() (int, int, time.Duration) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	errs := 0
+	for _, count := range r.errors {
+		errs += count
+	}
+
+	return r.requests, errs, time.Since(r.start)
+}
+
+func (r *recorder) report(agents int) *report {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	elapsed := time.Since(r.start).Seconds()
+	rep := &report{
+		Duration: elapsed,
+		Agents:   agents,
+		Requests: r.requests,
+		Metrics:  r.metrics,
+	}
+
+	for _, count := range r.errors {
+		rep.Errors += count
+	}
+
+	if len(r.errors) > 0 {
+		rep.ErrorKinds = r.errors
+		rep.ErrorSamples = r.samples
+	}
+
+	if rep.Requests > 0 {
+		rep.ErrorRate = float64(rep.Errors) / float64(rep.Requests)
+	}
+
+	if elapsed > 0 {
+		rep.RequestsPerSec = float64(rep.Requests) / elapsed
+		rep.MetricsPerSec = float64(rep.Metrics) / elapsed
+	}
+
+	sorted := slices.Clone(r.latencies)
+	slices.Sort(sorted)
+
+	rep.Latency = latencyReport{
+		P50: percentile(sorted, 0.50),
+		P95: percentile(sorted, 0.95),
+		P99: percentile(sorted, 0.99),
+		Max: float64(r.maxLatency) / float64(time.Millisecond),
+	}
+
+	return rep
+}
+
+// percentile returns the nearest-rank percentile of the sorted latencies in milliseconds.
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := max(int(math.Ceil(p*float64(len(sorted)))), 1)
+
+	return float64(sorted[rank-1]) / float64(time.Millisecond)
+}
+
+func errorKind(err error) string {
+	var statusErr *services.StatusError
+	if errors.As(err, &statusErr) {
+		return strconv.Itoa(statusErr.Code) + " " + http.StatusText(statusErr.Code)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+
+	return "transport"
+}
+
+func (r *report) print(out io.Writer, output string) error {
+	if output == outputJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("encode report error: %w", err)
+		}
+
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintf(tw, "duration\t%.1fs\n", r.Duration)
+	_, _ = fmt.Fprintf(tw, "agents\t%d\n", r.Agents)
+	_, _ = fmt.Fprintf(tw, "requests\t%d\t%.1f/s\n", r.Requests, r.RequestsPerSec)
+	_, _ = fmt.Fprintf(tw, "metrics\t%d\t%.1f/s\n", r.Metrics, r.MetricsPerSec)
+	_, _ = fmt.Fprintf(tw, "errors\t%d\t%.2f%%\n", r.Errors, r.ErrorRate*100)
+	_, _ = fmt.Fprintf(
+		tw,
+		"latency\tp50 %.2fms\tp95 %.2fms\tp99 %.2fms\tmax %.2fms\n",
+		r.Latency.P50,
+		r.Latency.P95,
+		r.Latency.P99,
+		r.Latency.Max,
+	)
+
+	kinds := make([]string, 0, len(r.ErrorKinds))
+	for kind := range r.ErrorKinds {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Strings(kinds)
+
+	for _, kind := range kinds {
+		_, _ = fmt.Fprintf(tw, "  %s\t%d\t%s\n", kind, r.ErrorKinds[kind], r.ErrorSamples[kind])
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("write report error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{
+		1 * time.Millisecond,
+		2 * time.Millisecond,
+		3 * time.Millisecond,
+		4 * time.Millisecond,
+		10 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   float64
+	}{
+		{name: "no samples", p: 0.5, want: 0},
+		{name: "median", sorted: sorted, p: 0.5, want: 3},
+		{name: "rank rounds up", sorted: sorted, p: 0.95, want: 10},
+		{name: "lowest rank is the first sample", sorted: sorted, p: 0, want: 1},
+		{name: "maximum", sorted: sorted, p: 1, want: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("percentile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecorder_observe(t *testing.T) {
+	tests := []struct {
+		name        string
+		requests    int
+		wantSamples int
+	}{
+		{name: "every latency is kept below the limit", requests: 1000, wantSamples: 1001},
+		{name: "latencies over the limit are sampled", requests: 3 * latencySamples, wantSamples: latencySamples},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := newRecorder()
+
+			for i := range tt.requests {
+				rec.observe(context.Background(), time.Duration(i%100)*time.Millisecond, 1, nil)
+			}
+
+			rec.observe(context.Background(), time.Second, 1, nil)
+
+			if got := len(rec.latencies); got != tt.wantSamples {
+				t.Errorf("samples = %d, want %d", got, tt.wantSamples)
+			}
+
+			rep := rec.report(1)
+			if rep.Requests != tt.requests+1 {
+				t.Errorf("requests = %d, want %d", rep.Requests, tt.requests+1)
+			}
+
+			if rep.Latency.Max != 1000 {
+				t.Errorf("max = %v, want 1000", rep.Latency.Max)
+			}
+
+			// The latencies are spread evenly over 0-99ms, a uniform sample keeps the median close to 50ms.
+			if rep.Latency.P50 < 45 || rep.Latency.P50 > 55 {
+				t.Errorf("p50 = %v, want about 50", rep.Latency.P50)
+			}
+		})
+	}
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 
 	"collector/internal/adapters/api/rest"
+	"collector/internal/adapters/keystore"
 	"collector/internal/adapters/store"
 	"collector/internal/config"
 	"collector/internal/controller"
@@ -24,12 +26,18 @@ func main() {
 		fx.WithLogger(func(log *slog.Logger) fxevent.Logger {
 			return &fxevent.SlogLogger{Logger: log}
 		}),
-		fx.Supply(domain.NewMetrics()),
+		fx.Supply(domain.NewTenants()),
 		fx.Provide(context.Background),
 		fx.Provide(parseEnvs),
 		fx.Provide(parseFlags),
 		fx.Provide(config.NewServerConfig),
 		fx.Provide(services.NewStoreService),
+		fx.Provide(services.NewUpdateBroker),
+		fx.Provide(services.NewWebhookNotifier),
+		fx.Provide(services.NewAlerter),
+		fx.Provide(keystore.NewKeyStore),
+		fx.Provide(services.NewKeyService),
+		fx.Provide(services.NewSeriesGuard),
 		fx.Provide(getStorage),
 		fx.Provide(newLogger),
 		fx.Provide(network.NewResponse),
@@ -47,18 +55,31 @@ func newHTTPServer(
 	logger *slog.Logger,
 	conf *config.ServerConfig,
 	storeService *services.StoreService,
+	broker *services.UpdateBroker,
+	alerter *services.Alerter,
+	keys *services.KeyService,
 ) *http.Server {
 	srv := &http.Server{
-		Addr:    conf.GetAddress(),
-		Handler: mux,
+		Addr:      conf.GetAddress(),
+		Handler:   mux,
+		TLSConfig: conf.GetTLSConfig(),
 	}
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			logger.Info("http server listening on " + srv.Addr)
 
+			listen := srv.ListenAndServe
+			if srv.TLSConfig != nil {
+				logger.Info("tls enabled")
+
+				listen = func() error {
+					return srv.ListenAndServeTLS("", "")
+				}
+			}
+
 			go func() {
-				if srvErr := srv.ListenAndServe(); srvErr != nil {
+				if srvErr := listen(); srvErr != nil && !errors.Is(srvErr, http.ErrServerClosed) {
 					logger.ErrorContext(ctx, "http server start error", slog.Any("error", srvErr))
 					if errShutdown := srv.Shutdown(context.Background()); errShutdown != nil {
 						logger.ErrorContext(
@@ -75,6 +96,13 @@ func newHTTPServer(
 				storeService.InitFlushStorageTicker(ctx, storeInterval)
 			}
 
+			if alerter.HasRules() {
+				alerter.InitEvaluationTicker(
+					context.WithoutCancel(ctx),
+					conf.GetAlertIntervalDuration(),
+				)
+			}
+
 			if conf.Restore {
 				if restoreErr := storeService.Restore(ctx); restoreErr != nil {
 					logger.ErrorContext(ctx, "restore storage error", slog.Any("error", restoreErr))
@@ -86,18 +114,32 @@ func newHTTPServer(
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
+			// Closing the broker ends the open streams, the server drains the remaining requests
+			// before the stores they use are flushed and closed.
+			broker.Close()
+
+			shutdownErr := srv.Shutdown(ctx)
+			if shutdownErr != nil {
+				logger.ErrorContext(ctx, "server shutdown error", slog.Any("error", shutdownErr))
+			}
+
 			if flushErr := storeService.Save(ctx); flushErr != nil {
 				logger.ErrorContext(ctx, "flush storage error", slog.Any("error", flushErr))
 
 				return flushErr
 			}
+			if closeErr := keys.Close(); closeErr != nil {
+				logger.ErrorContext(ctx, "failed to close key store", slog.Any("error", closeErr))
+
+				return closeErr
+			}
 			if closeErr := storeService.Close(); closeErr != nil {
 				logger.ErrorContext(ctx, "failed to close storage", slog.Any("error", closeErr))
 
 				return closeErr
 			}
 
-			return srv.Shutdown(ctx)
+			return shutdownErr
 		},
 	})
 	return srv
@@ -111,9 +153,9 @@ func getStorage(
 	ctx context.Context,
 	logger *slog.Logger,
 	conf *config.ServerConfig,
-	metrics *domain.Metrics,
+	tenants *domain.Tenants,
 ) store.Store {
-	st := store.NewStore(ctx, logger, conf, metrics)
+	st := store.NewStore(ctx, logger, conf, tenants)
 
 	logger.DebugContext(ctx, "Using store algo", slog.String("algo", string(st.GetStoreType())))
 
@@ -0,0 +1,25 @@
+package rest
+
+import (
+	"net/http"
+
+	"collector/internal/core/domain"
+	"collector/internal/core/services"
+	"collector/pkg/network"
+)
+
+func listAlerts(alerter *services.Alerter, resp *network.Response) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		state := domain.AlertState(req.URL.Query().Get("state"))
+
+		switch state {
+		case "", domain.AlertStatePending, domain.AlertStateFiring, domain.AlertStateResolved:
+		default:
+			resp.BadRequestError(writer, "unknown alert state")
+
+			return
+		}
+
+		resp.Send(req.Context(), writer, http.StatusOK, alerter.Alerts(domain.TenantFromContext(req.Context()), state))
+	}
+}
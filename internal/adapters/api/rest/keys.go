@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"collector/internal/core/domain"
+	"collector/internal/core/services"
+	"collector/pkg/network"
+	"github.com/go-chi/chi/v5"
+)
+
+type (
+	createKeyForm struct {
+		Scopes []domain.Scope `json:"scopes"`
+		Tenant string         `json:"tenant,omitempty"`
+	}
+	createdKey struct {
+		domain.APIKey
+		Key string `json:"key"`
+	}
+)
+
+func listKeys(keys *services.KeyService, logger *slog.Logger, resp *network.Response) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		list, listErr := keys.List(req.Context(), callerTenant(req))
+		if listErr != nil {
+			logger.ErrorContext(req.Context(), "list api keys error", slog.Any("error", listErr))
+			resp.ServerError(writer, http.StatusText(http.StatusInternalServerError))
+
+			return
+		}
+
+		resp.Send(req.Context(), writer, http.StatusOK, list)
+	}
+}
+
+func createKey(keys *services.KeyService, logger *slog.Logger, resp *network.Response) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		var form createKeyForm
+
+		if decodeErr := json.NewDecoder(req.Body).Decode(&form); decodeErr != nil {
+			resp.DecodeError(writer, decodeErr)
+
+			return
+		}
+
+		key, secret, createErr := keys.Create(req.Context(), callerTenant(req), form.Scopes, form.Tenant)
+		if errors.Is(createErr, domain.ErrForeignTenant) {
+			resp.Forbidden(writer, createErr.Error())
+
+			return
+		}
+
+		if createErr != nil {
+			if errors.Is(createErr, domain.ErrUnknownScope) ||
+				errors.Is(createErr, domain.ErrInvalidTenantID) {
+				resp.BadRequestError(writer, createErr.Error())
+
+				return
+			}
+
+			logger.ErrorContext(req.Context(), "create api key error", slog.Any("error", createErr))
+			resp.ServerError(writer, http.StatusText(http.StatusInternalServerError))
+
+			return
+		}
+
+		key.Hash = ""
+
+		resp.Send(req.Context(), writer, http.StatusCreated, createdKey{APIKey: *key, Key: secret})
+	}
+}
+
+func revokeKey(keys *services.KeyService, logger *slog.Logger, resp *network.Response) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		revokeErr := keys.Revoke(req.Context(), callerTenant(req), chi.URLParam(req, "id"))
+		if errors.Is(revokeErr, domain.ErrAPIKeyNotFound) {
+			http.NotFound(writer, req)
+
+			return
+		}
+
+		if revokeErr != nil {
+			logger.ErrorContext(req.Context(), "revoke api key error", slog.Any("error", revokeErr))
+			resp.ServerError(writer, http.StatusText(http.StatusInternalServerError))
+
+			return
+		}
+
+		writer.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// callerTenant returns the tenant the API key of the request is bound to, empty for global keys.
+func callerTenant(req *http.Request) string {
+	if key, ok := domain.APIKeyFromContext(req.Context()); ok {
+		return key.Tenant
+	}
+
+	return ""
+}
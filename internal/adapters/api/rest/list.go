@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"collector/internal/adapters/store"
+	"collector/internal/core/domain"
+	"collector/pkg/network"
+)
+
+func listMetrics(st store.Store, resp *network.Response) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+
+		filter, filterErr := newFilterByQuery(req)
+		if filterErr != nil {
+			resp.BadRequestError(writer, filterErr.Error())
+
+			return
+		}
+
+		limit := domain.DefaultPageLimit
+		if rawLimit := query.Get("limit"); rawLimit != "" {
+			var convErr error
+
+			limit, convErr = strconv.Atoi(rawLimit)
+			if convErr != nil || limit <= 0 {
+				resp.BadRequestError(writer, "invalid limit")
+
+				return
+			}
+		}
+
+		page, pageErr := domain.Paginate(
+			requestMetrics(st, req).Find(filter),
+			query.Get("cursor"),
+			limit,
+		)
+		if pageErr != nil {
+			if errors.Is(pageErr, domain.ErrInvalidCursor) {
+				resp.BadRequestError(writer, pageErr.Error())
+
+				return
+			}
+
+			resp.ServerError(writer, pageErr.Error())
+
+			return
+		}
+
+		resp.Send(req.Context(), writer, http.StatusOK, page)
+	}
+}
+
+func newFilterByQuery(req *http.Request) (*domain.MetricFilter, error) {
+	query := req.URL.Query()
+
+	return domain.NewMetricFilter(query.Get("type"), query.Get("match"), query.Get("regex"))
+}
+
+// aggregateMetrics summarizes the metrics of a single type, gauge values and counter totals don't add up.
+func aggregateMetrics(st store.Store, resp *network.Response) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		filter, filterErr := newFilterByQuery(req)
+		if filterErr != nil {
+			resp.BadRequestError(writer, filterErr.Error())
+
+			return
+		}
+
+		if filter.MType == "" {
+			resp.BadRequestError(writer, "type is required")
+
+			return
+		}
+
+		agg := domain.Aggregate(requestMetrics(st, req).Find(filter))
+
+		resp.Send(req.Context(), writer, http.StatusOK, agg)
+	}
+}
@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"collector/internal/adapters/store"
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/pkg/network"
+)
+
+func TestAggregateMetrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantCode  int
+		wantCount int
+		wantSum   float64
+	}{
+		{name: "type is required", query: "match=Poll*", wantCode: http.StatusBadRequest},
+		{name: "gauges only", query: "type=gauge&match=Poll*", wantCode: http.StatusOK, wantCount: 1, wantSum: 0.5},
+		{name: "counters only", query: "type=counter&match=Poll*", wantCode: http.StatusOK, wantCount: 1, wantSum: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenants := domain.NewTenants()
+			metrics := tenants.Get(domain.DefaultTenant)
+			metrics.SetGaugeValue("PollCount", 0.5)
+			metrics.AddCounterValue("PollCount", 7)
+
+			handler := aggregateMetrics(
+				store.NewMemoryStorage(tenants),
+				network.NewResponse(slog.Default(), &config.ServerConfig{}),
+			)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/aggregate?"+tt.query, nil))
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var got domain.Aggregation
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Count != tt.wantCount || got.Sum != tt.wantSum {
+				t.Errorf("aggregation = %d, %v, want %d, %v", got.Count, got.Sum, tt.wantCount, tt.wantSum)
+			}
+		})
+	}
+}
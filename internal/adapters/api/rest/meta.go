@@ -0,0 +1,65 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"collector/internal/adapters/store"
+	"collector/internal/core/domain"
+	"collector/pkg/network"
+)
+
+func listMeta(st store.Store, resp *network.Response) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		resp.Send(req.Context(), writer, http.StatusOK, requestMetrics(st, req).GetMetas())
+	}
+}
+
+// setMeta annotates existing series, the non-empty fields replace the stored ones.
+// Either all forms are applied or none.
+func setMeta(st store.Store, resp *network.Response) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		var forms []domain.MetricMetaForm
+
+		if decodeErr := json.NewDecoder(req.Body).Decode(&forms); decodeErr != nil {
+			resp.DecodeError(writer, decodeErr)
+
+			return
+		}
+
+		if len(forms) == 0 {
+			resp.BadRequestError(writer, "no metadata found")
+
+			return
+		}
+
+		metrics := requestMetrics(st, req)
+
+		for _, form := range forms {
+			if !form.MType.IsValid() {
+				resp.BadRequestError(writer, "unknown metric type")
+
+				return
+			}
+
+			if validateErr := form.Meta.Validate(); validateErr != nil {
+				resp.BadRequestError(writer, validateErr.Error())
+
+				return
+			}
+
+			if !metrics.Has(form.MType, form.ID) {
+				resp.BadRequestError(writer, fmt.Sprintf("unknown series %s/%s", form.MType, form.ID))
+
+				return
+			}
+		}
+
+		for _, form := range forms {
+			metrics.SetMeta(form.MType, form.ID, form.Meta)
+		}
+
+		resp.Success(writer)
+	}
+}
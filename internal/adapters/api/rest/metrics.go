@@ -32,6 +32,13 @@ func AllowedMetricsOnly(
 					slog.Any("error", decodeErr),
 					slog.Any("requestInfo", network.NewRequestInfo(req)),
 				)
+
+				if network.IsBodyTooLarge(decodeErr) {
+					resp.PayloadTooLarge(writer)
+
+					return
+				}
+
 				resp.BadRequestError(writer, http.StatusText(http.StatusBadRequest))
 
 				return
@@ -1,19 +1,24 @@
 package rest
 
 import (
+	"bufio"
 	"bytes"
-	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 	"time"
 
 	"collector/internal/config"
 	"collector/internal/core/domain"
-	"collector/pkg/hashing"
+	"collector/internal/core/services"
+	"collector/pkg/compression"
+	"collector/pkg/encryption"
 	"collector/pkg/network"
 	"github.com/google/uuid"
 )
@@ -49,20 +54,48 @@ func (resp *loggingResponseWriter) WriteHeader(statusCode int) {
 	resp.responseData.status = statusCode
 }
 
-type gzipWriter struct {
+func (resp *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return resp.ResponseWriter
+}
+
+func (resp *loggingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(resp.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, fmt.Errorf("hijack response error: %w", err)
+	}
+
+	resp.responseData.status = http.StatusSwitchingProtocols
+
+	return conn, rw, nil
+}
+
+type compressResponseWriter struct {
 	http.ResponseWriter
-	Writer io.Writer
+	Writer compression.Writer
 }
 
-func (writer gzipWriter) Write(b []byte) (int, error) {
+func (writer compressResponseWriter) Write(b []byte) (int, error) {
 	size, err := writer.Writer.Write(b)
 	if err != nil {
-		return 0, fmt.Errorf("gzip writer response error: %w", err)
+		return 0, fmt.Errorf("compress writer response error: %w", err)
 	}
 
 	return size, err
 }
 
+// FlushError pushes the compressed data buffered so far to the client, which streaming handlers rely on.
+func (writer compressResponseWriter) FlushError() error {
+	if err := writer.Writer.Flush(); err != nil {
+		return fmt.Errorf("compress writer flush error: %w", err)
+	}
+
+	return http.NewResponseController(writer.ResponseWriter).Flush()
+}
+
+func (writer compressResponseWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
 func LoggerMiddleware(logger *slog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(writer http.ResponseWriter, req *http.Request) {
@@ -99,68 +132,212 @@ func LoggerMiddleware(logger *slog.Logger) func(next http.Handler) http.Handler
 	}
 }
 
-func GzipMiddleware(logger *slog.Logger) func(next http.Handler) http.Handler {
+// TrustedSubnetMiddleware rejects clients outside the subnets, no subnets means no restriction.
+// The client address is taken from X-Real-IP only when the request comes from one of the proxies.
+func TrustedSubnetMiddleware(
+	subnets []netip.Prefix,
+	proxies []netip.Prefix,
+	resp *network.Response,
+	logger *slog.Logger,
+) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
+		if len(subnets) == 0 {
+			return next
+		}
+
 		fn := func(writer http.ResponseWriter, req *http.Request) {
-			contentEncoding := req.Header.Get("Content-Encoding")
+			ip, ipErr := network.ClientIP(req, proxies)
+			if ipErr != nil {
+				logger.WarnContext(req.Context(), "client ip error", slog.Any("error", ipErr))
+				resp.Forbidden(writer, http.StatusText(http.StatusForbidden))
 
-			sendsGzip := strings.Contains(contentEncoding, "gzip")
-			if sendsGzip {
-				gzReader, gzipReadErr := gzip.NewReader(req.Body)
-				if gzipReadErr != nil {
-					logger.ErrorContext(
+				return
+			}
+
+			if !network.ContainsIP(subnets, ip) {
+				logger.WarnContext(req.Context(), "untrusted client", slog.String("ip", ip.String()))
+				resp.Forbidden(writer, http.StatusText(http.StatusForbidden))
+
+				return
+			}
+
+			next.ServeHTTP(writer, req)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// BodyLimitMiddleware caps the request body as received on the wire.
+func BodyLimitMiddleware(conf *config.ServerConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(writer http.ResponseWriter, req *http.Request) {
+			if req.Body != nil {
+				req.Body = http.MaxBytesReader(writer, req.Body, conf.GetMaxBodyBytes())
+			}
+
+			next.ServeHTTP(writer, req)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ClientCertMiddleware binds the common name of a verified client certificate as the agent identity.
+func ClientCertMiddleware(logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(writer http.ResponseWriter, req *http.Request) {
+			if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+				next.ServeHTTP(writer, req)
+
+				return
+			}
+
+			agentID := req.TLS.VerifiedChains[0][0].Subject.CommonName
+			if agentID == "" {
+				next.ServeHTTP(writer, req)
+
+				return
+			}
+
+			logger.DebugContext(req.Context(), "client certificate", slog.String("agent", agentID))
+
+			next.ServeHTTP(writer, req.WithContext(domain.WithAgentID(req.Context(), agentID)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// DecryptMiddleware opens request bodies encrypted for the server key.
+// It runs before CompressMiddleware since agents compress the payload before encrypting it.
+func DecryptMiddleware(
+	conf *config.ServerConfig,
+	resp *network.Response,
+	logger *slog.Logger,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(writer http.ResponseWriter, req *http.Request) {
+			scheme := req.Header.Get(domain.EncryptionHeader)
+			if scheme == "" {
+				next.ServeHTTP(writer, req)
+
+				return
+			}
+
+			privateKey := conf.GetPrivateKey()
+			if privateKey == nil {
+				resp.BadRequestError(writer, "encryption is not configured")
+
+				return
+			}
+
+			if scheme != encryption.Scheme {
+				resp.BadRequestError(writer, "unsupported encryption scheme")
+
+				return
+			}
+
+			msg, readErr := io.ReadAll(req.Body)
+			if readErr != nil {
+				logger.ErrorContext(req.Context(), "read encrypted body error", slog.Any("error", readErr))
+				resp.DecodeError(writer, readErr)
+
+				return
+			}
+
+			plain, decryptErr := encryption.Decrypt(privateKey, msg)
+			if decryptErr != nil {
+				logger.WarnContext(req.Context(), "decrypt error", slog.Any("error", decryptErr))
+				resp.BadRequestError(writer, "cannot decrypt body")
+
+				return
+			}
+
+			req.Body = io.NopCloser(bytes.NewReader(plain))
+			req.ContentLength = int64(len(plain))
+			req.Header.Del(domain.EncryptionHeader)
+
+			next.ServeHTTP(writer, req)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// CompressMiddleware decodes zstd, br and gzip request bodies up to the decompressed size limit
+// and compresses responses with the encoding the client prefers in Accept-Encoding.
+func CompressMiddleware(conf *config.ServerConfig, logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(writer http.ResponseWriter, req *http.Request) {
+			contentEncoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+
+			if contentEncoding != "" && contentEncoding != compression.Identity {
+				reader, readerErr := compression.NewReader(contentEncoding, req.Body)
+				if readerErr != nil {
+					logger.WarnContext(
 						req.Context(),
 						"compress read error",
-						slog.Any("error", gzipReadErr),
+						slog.Any("error", readerErr),
 					)
 
+					if errors.Is(readerErr, compression.ErrUnsupportedEncoding) {
+						http.Error(writer, readerErr.Error(), http.StatusUnsupportedMediaType)
+
+						return
+					}
+
+					http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
 					return
 				}
 
-				req.Body = gzReader
+				req.Body = http.MaxBytesReader(writer, reader, conf.GetMaxDecompressedBytes())
+				req.Header.Del("Content-Encoding")
 
-				defer func(gzReader *gzip.Reader) {
-					gzReaderErr := gzReader.Close()
-					if gzReaderErr != nil {
+				defer func(reader io.ReadCloser) {
+					readerCloseErr := reader.Close()
+					if readerCloseErr != nil {
 						logger.ErrorContext(
 							req.Context(),
 							"compress close read error",
-							slog.Any("error", gzReaderErr),
+							slog.Any("error", readerCloseErr),
 						)
 					}
-				}(gzReader)
+				}(reader)
 			}
 
 			contentType := req.Header.Get("Content-Type")
-			enableEncoding := strings.Contains(req.Header.Get("Accept-Encoding"), "gzip")
+			encoding := compression.Negotiate(req.Header.Get("Accept-Encoding"))
 
-			if !enableEncoding || !isSupportedContentType(contentType) {
+			if encoding == "" || req.Header.Get("Upgrade") != "" || !isSupportedContentType(contentType) {
 				next.ServeHTTP(writer, req)
 
 				return
 			}
 
-			gzWriter, err := gzip.NewWriterLevel(writer, gzip.BestSpeed)
+			compressWriter, err := compression.NewWriter(encoding, writer)
 			if err != nil {
 				logger.ErrorContext(req.Context(), "compress error", slog.Any("error", err))
 
 				return
 			}
 
-			defer func(gz *gzip.Writer) {
-				closeGZipErr := gz.Close()
-				if closeGZipErr != nil {
+			defer func(cw compression.Writer) {
+				closeErr := cw.Close()
+				if closeErr != nil {
 					logger.ErrorContext(
 						req.Context(),
 						"close compress error",
-						slog.Any("error", closeGZipErr),
+						slog.Any("error", closeErr),
 					)
 				}
-			}(gzWriter)
+			}(compressWriter)
 
-			writer.Header().Set("Content-Encoding", "gzip")
+			writer.Header().Set("Content-Encoding", encoding)
+			writer.Header().Add("Vary", "Accept-Encoding")
 
-			next.ServeHTTP(gzipWriter{ResponseWriter: writer, Writer: gzWriter}, req)
+			next.ServeHTTP(compressResponseWriter{ResponseWriter: writer, Writer: compressWriter}, req)
 		}
 
 		return http.HandlerFunc(fn)
@@ -192,36 +369,80 @@ func RecoverMiddleware(logger *slog.Logger) func(next http.Handler) http.Handler
 	}
 }
 
+// CheckSignMiddleware verifies the HashSHA256 header against the request body.
+// Requests carrying a timestamp and nonce are verified against domain.RequestSignaturePayload
+// and rejected when stale or replayed, body-only signatures are still accepted outside strict mode.
+// In strict mode unsigned requests that carry data are rejected too,
+// read-only GET and HEAD requests are left to the auth layer.
+// The nonce cache is shared with the websocket ingestion, so a nonce is accepted once on either channel.
 func CheckSignMiddleware(
 	config *config.ServerConfig,
+	nonces *domain.NonceCache,
 	logger *slog.Logger,
 ) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(writer http.ResponseWriter, req *http.Request) {
-			hashKey := config.GetHashKey()
-			if hashKey != "" {
+			keySet := config.GetKeySet()
+			if !keySet.IsEmpty() {
 				headerHash := req.Header.Get(domain.HashHeader)
+				timestamp := req.Header.Get(domain.TimestampHeader)
+				nonce := req.Header.Get(domain.NonceHeader)
 				logger.InfoContext(
 					req.Context(),
 					"check sign",
 					slog.String("headerHash", headerHash),
+					slog.String("keyID", req.Header.Get(domain.HashKeyIDHeader)),
+					slog.String("timestamp", timestamp),
+					slog.String("nonce", nonce),
 				)
 
+				if headerHash == "" && config.IsHashStrict() && !isReadOnlyMethod(req.Method) {
+					logger.WarnContext(req.Context(), "unsigned request rejected")
+					network.NewResponse(
+						logger,
+						config,
+					).Unauthorized(writer, "signature required")
+
+					return
+				}
+
 				if headerHash != "" {
+					if timestamp == "" && nonce == "" && config.IsHashStrict() {
+						logger.WarnContext(req.Context(), "request without replay protection rejected")
+						network.NewResponse(
+							logger,
+							config,
+						).Unauthorized(writer, "signature timestamp and nonce required")
+
+						return
+					}
+
 					var bodyBuffer, bodyData bytes.Buffer
 					req.Body = io.NopCloser(io.TeeReader(req.Body, &bodyBuffer))
 					_, readErr := bodyData.ReadFrom(req.Body)
 
 					if readErr != nil {
 						logger.ErrorContext(req.Context(), "sign error", slog.Any("error", readErr))
+						network.NewResponse(logger, config).DecodeError(writer, readErr)
 
 						return
 					}
 
 					req.Body = io.NopCloser(&bodyBuffer)
 
-					hashBody := hashing.HashByKey(bodyData.String(), hashKey)
-					if headerHash != hashBody {
+					signed := bodyData.String()
+					if timestamp != "" || nonce != "" {
+						signed = domain.RequestSignaturePayload(
+							req.Method,
+							req.URL.RequestURI(),
+							timestamp,
+							nonce,
+							bodyData.Bytes(),
+						)
+					}
+
+					keyID := req.Header.Get(domain.HashKeyIDHeader)
+					if !keySet.Verify(signed, headerHash, keyID) {
 						network.NewResponse(
 							logger,
 							config,
@@ -229,6 +450,22 @@ func CheckSignMiddleware(
 
 						return
 					}
+
+					if timestamp != "" || nonce != "" {
+						if replayErr := checkReplay(nonces, timestamp, nonce); replayErr != nil {
+							logger.WarnContext(
+								req.Context(),
+								"replay check failed",
+								slog.Any("error", replayErr),
+							)
+							network.NewResponse(
+								logger,
+								config,
+							).Unauthorized(writer, replayErr.Error())
+
+							return
+						}
+					}
 				}
 			}
 
@@ -239,6 +476,19 @@ func CheckSignMiddleware(
 	}
 }
 
+func checkReplay(nonces *domain.NonceCache, timestamp string, nonce string) error {
+	at, parseErr := domain.ParseSignatureTimestamp(timestamp)
+	if parseErr != nil {
+		return parseErr
+	}
+
+	return nonces.Check(nonce, at, time.Now())
+}
+
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
 func RequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -248,11 +498,152 @@ func RequestIDMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// TenantMiddleware resolves the tenant from the tenant header, falling back to the default tenant.
+func TenantMiddleware(
+	resp *network.Response,
+	logger *slog.Logger,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(writer http.ResponseWriter, req *http.Request) {
+			tenant := req.Header.Get(domain.TenantHeader)
+			if tenant == "" {
+				next.ServeHTTP(writer, req)
+
+				return
+			}
+
+			if err := domain.ValidateTenantID(tenant); err != nil {
+				logger.WarnContext(
+					req.Context(),
+					"invalid tenant",
+					slog.String("tenant", tenant),
+				)
+				resp.BadRequestError(writer, err.Error())
+
+				return
+			}
+
+			next.ServeHTTP(writer, req.WithContext(domain.WithTenant(req.Context(), tenant)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// AuthMiddleware resolves the API key presented with the request and binds the request
+// to the key tenant. Requests without a key pass through and are rejected by RequireScope.
+func AuthMiddleware(
+	keys *services.KeyService,
+	resp *network.Response,
+	logger *slog.Logger,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(writer http.ResponseWriter, req *http.Request) {
+			secret := requestAPIKey(req)
+			if !keys.IsEnabled() || secret == "" {
+				next.ServeHTTP(writer, req)
+
+				return
+			}
+
+			key, authErr := keys.Authenticate(req.Context(), secret)
+			if authErr != nil {
+				logger.WarnContext(req.Context(), "authenticate error", slog.Any("error", authErr))
+				resp.Unauthorized(writer, http.StatusText(http.StatusUnauthorized))
+
+				return
+			}
+
+			ctx := domain.WithAPIKey(req.Context(), key)
+
+			if key.Tenant != "" {
+				if req.Header.Get(domain.TenantHeader) != "" &&
+					domain.TenantFromContext(ctx) != key.Tenant {
+					resp.Forbidden(writer, domain.ErrForeignTenant.Error())
+
+					return
+				}
+
+				ctx = domain.WithTenant(ctx, key.Tenant)
+			}
+
+			next.ServeHTTP(writer, req.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RequireAuth hides the routes that can't be left open when authentication is disabled.
+func RequireAuth(keys *services.KeyService) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(writer http.ResponseWriter, req *http.Request) {
+			if !keys.IsEnabled() {
+				http.NotFound(writer, req)
+
+				return
+			}
+
+			next.ServeHTTP(writer, req)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RequireScope rejects the requests whose API key doesn't grant the scope when authentication is enabled.
+func RequireScope(
+	keys *services.KeyService,
+	resp *network.Response,
+	scope domain.Scope,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(writer http.ResponseWriter, req *http.Request) {
+			if !keys.IsEnabled() {
+				next.ServeHTTP(writer, req)
+
+				return
+			}
+
+			key, ok := domain.APIKeyFromContext(req.Context())
+			if !ok {
+				resp.Unauthorized(writer, http.StatusText(http.StatusUnauthorized))
+
+				return
+			}
+
+			if !key.HasScope(scope) {
+				resp.Forbidden(writer, "api key lacks the "+string(scope)+" scope")
+
+				return
+			}
+
+			next.ServeHTTP(writer, req)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func requestAPIKey(req *http.Request) string {
+	if key := req.Header.Get(domain.APIKeyHeader); key != "" {
+		return key
+	}
+
+	if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
+
 func isSupportedContentType(contentType string) bool {
 	if contentType == "" {
 		contentType = "text/html"
 	}
 
-	return strings.Contains(contentType, "application/json") ||
+	return strings.Contains(contentType, domain.ContentTypeJSON) ||
+		strings.Contains(contentType, domain.ContentTypeProtobuf) ||
+		strings.Contains(contentType, domain.ContentTypeMsgPack) ||
 		strings.Contains(contentType, "text/html")
 }
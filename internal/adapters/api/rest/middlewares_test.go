@@ -0,0 +1,324 @@
+package rest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/pkg/hashing"
+	"collector/pkg/network"
+	"collector/pkg/tlsconfig"
+	"collector/pkg/tlsconfig/tlstest"
+)
+
+func TestTrustedSubnetMiddleware(t *testing.T) {
+	subnets := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	proxies := []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")}
+
+	tests := []struct {
+		name     string
+		peer     string
+		realIP   string
+		proxies  []netip.Prefix
+		wantCode int
+	}{
+		{name: "trusted peer", peer: "10.1.2.3:5000", wantCode: http.StatusOK},
+		{name: "untrusted peer", peer: "192.0.2.1:5000", wantCode: http.StatusForbidden},
+		{
+			name:     "spoofed header from an untrusted peer",
+			peer:     "192.0.2.1:5000",
+			realIP:   "10.0.0.1",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "header from a peer that isn't a proxy",
+			peer:     "192.0.2.1:5000",
+			realIP:   "10.0.0.1",
+			proxies:  proxies,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "trusted header from a proxy",
+			peer:     "127.0.0.1:5000",
+			realIP:   "10.0.0.1",
+			proxies:  proxies,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "untrusted header from a proxy",
+			peer:     "127.0.0.1:5000",
+			realIP:   "192.0.2.1",
+			proxies:  proxies,
+			wantCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.Default()
+			resp := network.NewResponse(logger, &config.ServerConfig{})
+			handler := TrustedSubnetMiddleware(subnets, tt.proxies, resp, logger)(
+				http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }),
+			)
+
+			req := httptest.NewRequest(http.MethodPost, "/update/", nil)
+			req.RemoteAddr = tt.peer
+
+			if tt.realIP != "" {
+				req.Header.Set(network.RealIPHeader, tt.realIP)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// countingReader counts the bytes the server has read from the wire.
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+
+	return n, err
+}
+
+func TestBodyLimits(t *testing.T) {
+	const (
+		maxBody         = 1 << 10
+		maxDecompressed = 4 << 10
+	)
+
+	var bomb bytes.Buffer
+
+	gz := gzip.NewWriter(&bomb)
+	_, _ = gz.Write(bytes.Repeat([]byte(" "), 64<<20))
+	_ = gz.Close()
+
+	tests := []struct {
+		name     string
+		body     []byte
+		encoding string
+		maxBody  int
+		wantCode int
+		maxRead  int
+	}{
+		{name: "body within the limit", body: []byte(`[]`), maxBody: maxBody, wantCode: http.StatusOK, maxRead: maxBody},
+		{
+			name:     "oversized body",
+			body:     bytes.Repeat([]byte(" "), 16<<20),
+			maxBody:  maxBody,
+			wantCode: http.StatusRequestEntityTooLarge,
+			maxRead:  64 << 10,
+		},
+		{
+			name:     "gzip bomb",
+			body:     bomb.Bytes(),
+			encoding: "gzip",
+			maxBody:  bomb.Len(),
+			wantCode: http.StatusRequestEntityTooLarge,
+			maxRead:  bomb.Len() / 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.Default()
+			conf := &config.ServerConfig{MaxBodyBytes: tt.maxBody, MaxDecompressedBytes: maxDecompressed}
+			resp := network.NewResponse(logger, conf)
+
+			decode := http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+				if _, decodeErr := domain.NewFormArrayByRequest(req); decodeErr != nil {
+					resp.DecodeError(writer, decodeErr)
+
+					return
+				}
+
+				writer.WriteHeader(http.StatusOK)
+			})
+			handler := BodyLimitMiddleware(conf)(CompressMiddleware(conf, logger)(decode))
+
+			body := &countingReader{r: bytes.NewReader(tt.body)}
+			req := httptest.NewRequest(http.MethodPost, "/updates/", body)
+			req.Header.Set("Content-Type", domain.ContentTypeJSON)
+
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+
+			if body.read > tt.maxRead {
+				t.Errorf("read %d of %d body bytes, want at most %d", body.read, len(tt.body), tt.maxRead)
+			}
+		})
+	}
+}
+
+func newTestConfig(t *testing.T, key string) *config.ServerConfig {
+	t.Helper()
+
+	conf, confErr := config.NewServerConfig(&config.FlagContainer{
+		AppType:              config.AppTypeServer,
+		HashKey:              key,
+		RateWindow:           60,
+		AlertInterval:        10,
+		ReplayWindow:         300,
+		MaxBodyBytes:         1 << 20,
+		MaxDecompressedBytes: 1 << 20,
+		MetricNamePattern:    domain.DefaultMetricNamePattern,
+		MetricNameMaxLen:     domain.DefaultMetricNameMaxLen,
+	}, &config.EnvContainer{})
+	if confErr != nil {
+		t.Fatal(confErr)
+	}
+
+	return conf
+}
+
+func TestCheckSignMiddleware(t *testing.T) {
+	const key = "secret"
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		signedAs string
+		strict   bool
+		wantCode int
+	}{
+		{
+			name:     "signed request",
+			method:   http.MethodPost,
+			target:   "/update/gauge/Alloc/1",
+			signedAs: "/update/gauge/Alloc/1",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "rewritten path value",
+			method:   http.MethodPost,
+			target:   "/update/gauge/Alloc/1000",
+			signedAs: "/update/gauge/Alloc/1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unsigned post in strict mode",
+			method:   http.MethodPost,
+			target:   "/update/gauge/Alloc/1",
+			strict:   true,
+			wantCode: http.StatusUnauthorized,
+		},
+		{name: "unsigned post", method: http.MethodPost, target: "/update/gauge/Alloc/1", wantCode: http.StatusOK},
+		{name: "unsigned get in strict mode", method: http.MethodGet, target: "/", strict: true, wantCode: http.StatusOK},
+		{name: "unsigned head in strict mode", method: http.MethodHead, target: "/", strict: true, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := newTestConfig(t, key)
+			conf.HashStrict = tt.strict
+
+			handler := CheckSignMiddleware(conf, domain.NewNonceCache(time.Minute), slog.Default())(
+				http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+			)
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+
+			if tt.signedAs != "" {
+				timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+				nonce, nonceErr := domain.NewNonce()
+				if nonceErr != nil {
+					t.Fatal(nonceErr)
+				}
+
+				signed := domain.RequestSignaturePayload(tt.method, tt.signedAs, timestamp, nonce, nil)
+
+				req.Header.Set(domain.TimestampHeader, timestamp)
+				req.Header.Set(domain.NonceHeader, nonce)
+				req.Header.Set(domain.HashHeader, hashing.HashByKey(signed, key))
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestClientCertMiddleware(t *testing.T) {
+	serverCA := tlstest.NewCA(t, "server-ca")
+	clientCA := tlstest.NewCA(t, "client-ca")
+	serverCert, serverKey := serverCA.Issue(t, "server")
+
+	tests := []struct {
+		name       string
+		commonName string
+		wantAgent  string
+	}{
+		{name: "common name is the agent id", commonName: "agent-1", wantAgent: "agent-1"},
+		{name: "certificate without a common name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverConf, serverErr := tlsconfig.NewServer(serverCert, serverKey, tls.VersionTLS12, clientCA.File)
+			if serverErr != nil {
+				t.Fatal(serverErr)
+			}
+
+			var gotAgent string
+
+			srv := httptest.NewUnstartedServer(ClientCertMiddleware(slog.Default())(
+				http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+					gotAgent, _ = domain.AgentIDFromContext(req.Context())
+				}),
+			))
+			srv.TLS = serverConf
+			srv.StartTLS()
+			t.Cleanup(srv.Close)
+
+			certFile, keyFile := clientCA.Issue(t, tt.commonName)
+
+			clientConf, clientErr := tlsconfig.NewClient(serverCA.File, certFile, keyFile, tls.VersionTLS12)
+			if clientErr != nil {
+				t.Fatal(clientErr)
+			}
+
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConf}}
+
+			resp, respErr := client.Get(srv.URL)
+			if respErr != nil {
+				t.Fatal(respErr)
+			}
+
+			_ = resp.Body.Close()
+
+			if gotAgent != tt.wantAgent {
+				t.Errorf("agent id = %q, want %q", gotAgent, tt.wantAgent)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"bytes"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"collector/internal/adapters/store"
+	"collector/internal/core/domain"
+)
+
+var metricsPage = template.Must(template.New("metrics").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Metrics</title></head>
+<body>
+<table>
+<tr><th>Name</th><th>Type</th><th>Value</th><th>Unit</th><th>Help</th><th>Owner</th></tr>
+{{- range . }}
+<tr><td>{{ .ID }}</td><td>{{ .Type }}</td><td>{{ .Value }}</td><td>{{ .Meta.Unit }}</td><td>{{ .Meta.Help }}</td><td>{{ .Meta.Owner }}</td></tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
+
+type metricsPageRow struct {
+	ID    string
+	Type  domain.MetricType
+	Value string
+	Meta  domain.MetricMeta
+}
+
+// showMetrics renders the metrics of the tenant along with their metadata as an HTML table.
+func showMetrics(st store.Store, logger *slog.Logger) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		forms := requestMetrics(st, req).Find(&domain.MetricFilter{})
+		rows := make([]metricsPageRow, 0, len(forms))
+
+		for _, form := range forms {
+			row := metricsPageRow{ID: form.ID, Type: form.MType}
+
+			if form.IsGaugeType() {
+				row.Value = strconv.FormatFloat(*form.Value, 'g', -1, 64)
+			} else {
+				row.Value = strconv.FormatInt(*form.Delta, 10)
+			}
+
+			if form.Meta != nil {
+				row.Meta = *form.Meta
+			}
+
+			rows = append(rows, row)
+		}
+
+		var buf bytes.Buffer
+
+		if err := metricsPage.Execute(&buf, rows); err != nil {
+			logger.ErrorContext(req.Context(), "render metrics page error", slog.Any("error", err))
+			http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		writer.WriteHeader(http.StatusOK)
+
+		if _, err := writer.Write(buf.Bytes()); err != nil {
+			logger.ErrorContext(req.Context(), "write metrics page error", slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"collector/internal/adapters/store"
+	"collector/internal/config"
+	"collector/internal/core/domain"
+)
+
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// prometheusSeries is a single exposed series with its type and annotations.
+type prometheusSeries struct {
+	name  string
+	mType string
+	value float64
+	meta  *domain.MetricMeta
+}
+
+// exposeMetrics renders the metrics in the Prometheus text exposition format.
+// Counters are exposed as <name>_total, each accompanied by a <name>_rate gauge computed over the configured window.
+func exposeMetrics(
+	st store.Store,
+	conf *config.ServerConfig,
+	logger *slog.Logger,
+) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		metrics := requestMetrics(st, req)
+		window := conf.GetRateWindowDuration()
+		now := time.Now()
+
+		var gauges, counters []prometheusSeries
+
+		for _, form := range metrics.Find(&domain.MetricFilter{}) {
+			name := prometheusName(form.ID)
+
+			if form.IsGaugeType() {
+				gauges = append(gauges, prometheusSeries{name: name, mType: "gauge", value: *form.Value, meta: form.Meta})
+
+				continue
+			}
+
+			counters = append(
+				counters,
+				prometheusSeries{name: name + "_total", mType: "counter", value: float64(*form.Delta), meta: form.Meta},
+			)
+
+			if rate, hasRate := metrics.GetCounterRate(form.ID, window, now); hasRate {
+				counters = append(counters, prometheusSeries{name: name + "_rate", mType: "gauge", value: rate.Rate})
+			}
+		}
+
+		var buf bytes.Buffer
+
+		// Prometheus rejects the whole scrape when a name repeats, so the first series of a name wins.
+		// Gauges go first to keep their names over the series derived from counters.
+		exposed := make(map[string]bool, len(gauges)+len(counters))
+
+		for _, series := range append(gauges, counters...) {
+			if exposed[series.name] {
+				logger.DebugContext(req.Context(), "duplicate prometheus series skipped", slog.String("name", series.name))
+
+				continue
+			}
+
+			exposed[series.name] = true
+
+			writePrometheusMeta(&buf, series.name, series.meta)
+			writePrometheusSample(&buf, series.name, series.mType, series.value)
+		}
+
+		writer.Header().Set("Content-Type", prometheusContentType)
+		writer.WriteHeader(http.StatusOK)
+
+		if _, err := writer.Write(buf.Bytes()); err != nil {
+			logger.ErrorContext(req.Context(), "write metrics error", slog.Any("error", err))
+		}
+	}
+}
+
+func writePrometheusSample(buf *bytes.Buffer, name string, mType string, value float64) {
+	_, _ = fmt.Fprintf(buf, "# TYPE %s %s\n", name, mType)
+	_, _ = fmt.Fprintf(buf, "%s %s\n", name, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// writePrometheusMeta writes the HELP line of the annotated series.
+// Units aren't written, the UNIT line belongs to OpenMetrics and not to the text format served here.
+func writePrometheusMeta(buf *bytes.Buffer, name string, meta *domain.MetricMeta) {
+	if meta == nil {
+		return
+	}
+
+	if meta.Help != "" {
+		_, _ = fmt.Fprintf(buf, "# HELP %s %s\n", name, prometheusHelpReplacer.Replace(meta.Help))
+	}
+}
+
+var prometheusHelpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// prometheusName replaces the characters not allowed in Prometheus metric names.
+func prometheusName(name string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+			(r >= '0' && r <= '9') {
+			return r
+		}
+
+		return '_'
+	}, name)
+
+	if sanitized == "" || (sanitized[0] >= '0' && sanitized[0] <= '9') {
+		sanitized = "_" + sanitized
+	}
+
+	return sanitized
+}
@@ -0,0 +1,75 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"collector/internal/adapters/store"
+	"collector/internal/config"
+	"collector/internal/core/domain"
+)
+
+func TestExposeMetrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		gauges    []string
+		counters  []string
+		wantTypes []string
+	}{
+		{
+			name:      "gauge and counter with the same id",
+			gauges:    []string{"Poll"},
+			counters:  []string{"Poll"},
+			wantTypes: []string{"Poll gauge", "Poll_total counter", "Poll_rate gauge"},
+		},
+		{
+			name:      "ids sanitized to the same name",
+			gauges:    []string{"heap.alloc", "heap-alloc"},
+			wantTypes: []string{"heap_alloc gauge"},
+		},
+		{
+			name:      "gauge named as a counter rate",
+			gauges:    []string{"Poll_rate"},
+			counters:  []string{"Poll"},
+			wantTypes: []string{"Poll_rate gauge", "Poll_total counter"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenants := domain.NewTenants()
+			metrics := tenants.Get(domain.DefaultTenant)
+
+			for _, name := range tt.gauges {
+				metrics.SetGaugeValue(name, 1)
+			}
+
+			for _, name := range tt.counters {
+				metrics.AddCounterValue(name, 1)
+			}
+
+			handler := exposeMetrics(store.NewMemoryStorage(tenants), &config.ServerConfig{}, slog.Default())
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+			var gotTypes []string
+
+			for _, line := range strings.Split(rec.Body.String(), "\n") {
+				if typ, ok := strings.CutPrefix(line, "# TYPE "); ok {
+					gotTypes = append(gotTypes, typ)
+				}
+			}
+
+			slices.Sort(gotTypes)
+			slices.Sort(tt.wantTypes)
+
+			if !slices.Equal(gotTypes, tt.wantTypes) {
+				t.Errorf("TYPE lines = %v, want %v", gotTypes, tt.wantTypes)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/pkg/network"
+	"collector/pkg/ratelimit"
+)
+
+// RateLimits groups the limiters of the update routes.
+// Single and batch limits apply per agent, the global one to all agents together.
+type RateLimits struct {
+	single *ratelimit.Limiter
+	batch  *ratelimit.Limiter
+	global *ratelimit.Limiter
+}
+
+func NewRateLimits(conf *config.ServerConfig) *RateLimits {
+	return &RateLimits{
+		single: ratelimit.New(conf.GetLimitUpdateRPS(), conf.GetLimitBurst()),
+		batch:  ratelimit.New(conf.GetLimitBatchRPS(), conf.GetLimitBurst()),
+		global: ratelimit.New(conf.GetLimitGlobalRPS(), conf.GetLimitBurst()),
+	}
+}
+
+// allow checks the agent limit first so a throttled agent doesn't spend the global budget,
+// the agent token is refunded when the global limit rejects the request.
+func (l *RateLimits) allow(limiter *ratelimit.Limiter, key string, now time.Time) (bool, time.Duration) {
+	if ok, retryAfter := limiter.Allow(key, now); !ok {
+		return false, retryAfter
+	}
+
+	ok, retryAfter := l.global.Allow("", now)
+	if !ok {
+		limiter.Refund(key)
+	}
+
+	return ok, retryAfter
+}
+
+func RateLimitMiddleware(
+	limits *RateLimits,
+	limiter *ratelimit.Limiter,
+	resp *network.Response,
+	logger *slog.Logger,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(writer http.ResponseWriter, req *http.Request) {
+			key := domain.ClientIDFromContext(req.Context())
+
+			if ok, retryAfter := limits.allow(limiter, key, time.Now()); !ok {
+				logger.WarnContext(
+					req.Context(),
+					"rate limited",
+					slog.String("client", key),
+					slog.Duration("retryAfter", retryAfter),
+				)
+				resp.TooManyRequests(writer, retryAfter)
+
+				return
+			}
+
+			next.ServeHTTP(writer, req)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ClientKeyMiddleware binds the key the client is accounted under for rate and series limits.
+// It has to run after the authentication middleware.
+func ClientKeyMiddleware(proxies []netip.Prefix) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(writer http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(writer, req.WithContext(domain.WithClientID(req.Context(), clientKey(req, proxies))))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// clientKey identifies the client by its certificate, then its api key and finally its address.
+// The address comes from X-Real-IP only behind a trusted proxy, so a client can't pick its own bucket.
+func clientKey(req *http.Request, proxies []netip.Prefix) string {
+	if agentID, ok := domain.AgentIDFromContext(req.Context()); ok {
+		return "agent:" + agentID
+	}
+
+	if key, ok := domain.APIKeyFromContext(req.Context()); ok {
+		return "key:" + key.ID
+	}
+
+	if ip, ipErr := network.ClientIP(req, proxies); ipErr == nil {
+		return "ip:" + ip.String()
+	}
+
+	return "addr:" + req.RemoteAddr
+}
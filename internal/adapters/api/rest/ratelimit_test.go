@@ -0,0 +1,96 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"collector/internal/core/domain"
+	"collector/pkg/network"
+	"collector/pkg/ratelimit"
+)
+
+func Test_clientKey(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")}
+
+	tests := []struct {
+		name   string
+		peer   string
+		realIP string
+		apiKey *domain.APIKey
+		want   string
+	}{
+		{name: "peer address", peer: "192.0.2.1:5000", want: "ip:192.0.2.1"},
+		{name: "forged header is ignored", peer: "192.0.2.1:5000", realIP: "10.0.0.7", want: "ip:192.0.2.1"},
+		{name: "header from a trusted proxy", peer: "127.0.0.1:5000", realIP: "10.0.0.7", want: "ip:10.0.0.7"},
+		{
+			name:   "api key wins over the address",
+			peer:   "127.0.0.1:5000",
+			realIP: "10.0.0.7",
+			apiKey: &domain.APIKey{ID: "k1"},
+			want:   "key:k1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/update/", nil)
+			req.RemoteAddr = tt.peer
+
+			if tt.realIP != "" {
+				req.Header.Set(network.RealIPHeader, tt.realIP)
+			}
+
+			if tt.apiKey != nil {
+				req = req.WithContext(domain.WithAPIKey(req.Context(), tt.apiKey))
+			}
+
+			if got := clientKey(req, proxies); got != tt.want {
+				t.Errorf("clientKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimits_allow(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	type call struct {
+		key  string
+		at   time.Duration
+		want bool
+	}
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{
+			name: "agent limit",
+			calls: []call{
+				{key: "a", want: true},
+				{key: "a", at: 200 * time.Millisecond, want: false},
+			},
+		},
+		{
+			name: "global reject keeps the agent token",
+			calls: []call{
+				{key: "a", want: true},
+				{key: "c", want: true},
+				{key: "b", want: false},
+				{key: "b", at: 500 * time.Millisecond, want: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := &RateLimits{single: ratelimit.New(1, 1), global: ratelimit.New(2, 2)}
+
+			for i, c := range tt.calls {
+				if got, _ := limits.allow(limits.single, c.key, now.Add(c.at)); got != c.want {
+					t.Errorf("call %d: allow(%q) = %v, want %v", i, c.key, got, c.want)
+				}
+			}
+		})
+	}
+}
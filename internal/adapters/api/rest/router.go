@@ -1,13 +1,16 @@
 package rest
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"collector/internal/adapters/store"
 	"collector/internal/config"
 	"collector/internal/core/domain"
+	"collector/internal/core/services"
 	"collector/pkg/network"
 	"github.com/go-chi/chi/v5"
 )
@@ -19,115 +22,231 @@ const (
 
 func NewRouter(
 	st store.Store,
+	broker *services.UpdateBroker,
+	alerter *services.Alerter,
+	keys *services.KeyService,
+	guard *services.SeriesGuard,
 	logger *slog.Logger,
 	conf *config.ServerConfig,
 	resp *network.Response,
 ) *chi.Mux {
 	router := chi.NewRouter()
+	limits := NewRateLimits(conf)
+	nonces := domain.NewNonceCache(conf.GetReplayWindowDuration())
 
-	registerMiddlewares(router, logger, conf)
-	registerMultipleMetricRoutes(st, router, logger, resp)
-	registerSingleMetricRoutes(st, router, logger, resp)
+	registerMiddlewares(router, keys, nonces, logger, conf, resp)
+	registerMultipleMetricRoutes(st, broker, keys, guard, limits, router, logger, conf, resp)
+	registerAPIRoutes(st, broker, alerter, keys, guard, limits, nonces, router, logger, conf, resp)
+	registerSingleMetricRoutes(st, broker, keys, guard, limits, router, logger, conf, resp)
 
 	return router
 }
 
 func registerMultipleMetricRoutes(
 	st store.Store,
+	broker *services.UpdateBroker,
+	keys *services.KeyService,
+	guard *services.SeriesGuard,
+	limits *RateLimits,
 	router *chi.Mux,
 	logger *slog.Logger,
+	conf *config.ServerConfig,
 	resp *network.Response,
 ) chi.Router {
 	return router.Group(func(r chi.Router) {
-		r.Get("/", func(w http.ResponseWriter, _ *http.Request) {
-			w.Header().Add("Content-Type", "text/html")
-			w.WriteHeader(http.StatusOK)
-		})
 		r.Get("/ping", pingDB(st, resp))
-		r.Post("/updates/", updateMetrics(st, logger, resp))
+
+		r.Group(func(r chi.Router) {
+			r.Use(TrustedSubnetMiddleware(conf.GetTrustedReadSubnets(), conf.GetTrustedProxies(), resp, logger))
+			r.Use(RequireScope(keys, resp, domain.ScopeRead))
+			r.Get("/", showMetrics(st, logger))
+			r.Get("/metrics", exposeMetrics(st, conf, logger))
+		})
+
+		r.With(
+			TrustedSubnetMiddleware(conf.GetTrustedSubnets(), conf.GetTrustedProxies(), resp, logger),
+			RequireScope(keys, resp, domain.ScopeWrite),
+			RateLimitMiddleware(limits, limits.batch, resp, logger),
+		).Post("/updates/", updateMetrics(st, broker, guard, logger, resp))
 	})
 }
 
-func registerMiddlewares(router *chi.Mux, logger *slog.Logger, conf *config.ServerConfig) {
+func registerAPIRoutes(
+	st store.Store,
+	broker *services.UpdateBroker,
+	alerter *services.Alerter,
+	keys *services.KeyService,
+	guard *services.SeriesGuard,
+	limits *RateLimits,
+	nonces *domain.NonceCache,
+	router *chi.Mux,
+	logger *slog.Logger,
+	conf *config.ServerConfig,
+	resp *network.Response,
+) {
+	router.Route("/api/v1", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(TrustedSubnetMiddleware(conf.GetTrustedReadSubnets(), conf.GetTrustedProxies(), resp, logger))
+			r.Use(RequireScope(keys, resp, domain.ScopeRead))
+			r.Get("/metrics", listMetrics(st, resp))
+			r.Get("/aggregate", aggregateMetrics(st, resp))
+			r.Get("/stream", streamMetrics(broker, logger, resp))
+			r.Get("/alerts", listAlerts(alerter, resp))
+			r.Get("/meta", listMeta(st, resp))
+		})
+
+		r.With(
+			TrustedSubnetMiddleware(conf.GetTrustedSubnets(), conf.GetTrustedProxies(), resp, logger),
+			RequireScope(keys, resp, domain.ScopeWrite),
+			RateLimitMiddleware(limits, limits.batch, resp, logger),
+		).Put("/meta", setMeta(st, resp))
+
+		r.With(
+			TrustedSubnetMiddleware(conf.GetTrustedSubnets(), conf.GetTrustedProxies(), resp, logger),
+			RequireScope(keys, resp, domain.ScopeWrite),
+		).Get("/ws", ingestWebSocket(st, broker, guard, limits, nonces, conf, logger))
+
+		r.Route("/keys", func(r chi.Router) {
+			r.Use(RequireAuth(keys))
+			r.Use(RequireScope(keys, resp, domain.ScopeAdmin))
+			r.Get("/", listKeys(keys, logger, resp))
+			r.Post("/", createKey(keys, logger, resp))
+			r.Delete("/{id}", revokeKey(keys, logger, resp))
+		})
+
+		registerSnapshotRoutes(st, keys, guard, r, logger, resp)
+	})
+}
+
+// registerSnapshotRoutes exposes the snapshot API only with authentication enabled,
+// an open import would let anyone replace the metrics of a tenant.
+func registerSnapshotRoutes(
+	st store.Store,
+	keys *services.KeyService,
+	guard *services.SeriesGuard,
+	router chi.Router,
+	logger *slog.Logger,
+	resp *network.Response,
+) {
+	router.Route("/snapshot", func(r chi.Router) {
+		r.Use(RequireAuth(keys))
+		r.Use(RequireScope(keys, resp, domain.ScopeAdmin))
+		r.Get("/", exportSnapshot(st, logger))
+		r.Post("/", importSnapshot(st, guard, logger, resp))
+	})
+}
+
+func registerMiddlewares(
+	router *chi.Mux,
+	keys *services.KeyService,
+	nonces *domain.NonceCache,
+	logger *slog.Logger,
+	conf *config.ServerConfig,
+	resp *network.Response,
+) {
 	router.Use(RequestIDMiddleware)
 	router.Use(LoggerMiddleware(logger))
 	router.Use(RecoverMiddleware(logger))
-	router.Use(GzipMiddleware(logger))
-	router.Use(CheckSignMiddleware(conf, logger))
+	router.Use(BodyLimitMiddleware(conf))
+	router.Use(ClientCertMiddleware(logger))
+	router.Use(DecryptMiddleware(conf, resp, logger))
+	router.Use(CompressMiddleware(conf, logger))
+	router.Use(CheckSignMiddleware(conf, nonces, logger))
+	router.Use(TenantMiddleware(resp, logger))
+	router.Use(AuthMiddleware(keys, resp, logger))
+	router.Use(ClientKeyMiddleware(conf.GetTrustedProxies()))
 }
 
 func registerSingleMetricRoutes(
 	st store.Store,
+	broker *services.UpdateBroker,
+	keys *services.KeyService,
+	guard *services.SeriesGuard,
+	limits *RateLimits,
 	router *chi.Mux,
 	logger *slog.Logger,
+	conf *config.ServerConfig,
 	resp *network.Response,
 ) {
 	router.Route("/", func(r chi.Router) {
 		r.Use(AllowedMetricsOnly(resp, logger))
-		r.Post("/update/", updateMetric(st, logger, resp))
-		r.Post("/value/", getMetric(st, logger, resp))
 
-		r.Get("/value/counter/{metric}", getCounter(st, resp))
-		r.Get("/value/gauge/{metric}", getGauge(st, resp))
+		r.Group(func(r chi.Router) {
+			r.Use(TrustedSubnetMiddleware(conf.GetTrustedReadSubnets(), conf.GetTrustedProxies(), resp, logger))
+			r.Use(RequireScope(keys, resp, domain.ScopeRead))
+			r.Post("/value/", getMetric(st, logger, resp))
+
+			r.Get("/value/counter/{metric}", getCounter(st, resp))
+			r.Get("/value/counter/{metric}/rate", getCounterRate(st, conf, resp))
+			r.Get("/value/gauge/{metric}", getGauge(st, resp))
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(TrustedSubnetMiddleware(conf.GetTrustedSubnets(), conf.GetTrustedProxies(), resp, logger))
+			r.Use(RequireScope(keys, resp, domain.ScopeWrite))
+			r.Use(RateLimitMiddleware(limits, limits.single, resp, logger))
+			r.Post("/update/", updateMetric(st, broker, guard, logger, resp))
 
-		r.Post("/update/counter/{metric}/{value}", updateCounter(st, resp))
-		r.Post("/update/gauge/{metric}/{value}", updateGauge(st, resp))
-		r.Post("/update/counter/", http.NotFound)
-		r.Post("/update/gauge/", http.NotFound)
+			r.Post("/update/counter/{metric}/{value}", updateCounter(st, broker, guard, resp))
+			r.Post("/update/gauge/{metric}/{value}", updateGauge(st, broker, guard, resp))
+			r.Post("/update/counter/", http.NotFound)
+			r.Post("/update/gauge/", http.NotFound)
 
-		r.Post("/", func(w http.ResponseWriter, _ *http.Request) {
-			resp.Success(w)
+			r.Post("/", func(w http.ResponseWriter, _ *http.Request) {
+				resp.Success(w)
+			})
 		})
 	})
 }
 
-func updateMetric(st store.Store, logger *slog.Logger, resp *network.Response) http.HandlerFunc {
+func updateMetric(
+	st store.Store,
+	broker *services.UpdateBroker,
+	guard *services.SeriesGuard,
+	logger *slog.Logger,
+	resp *network.Response,
+) http.HandlerFunc {
 	return func(writer http.ResponseWriter, req *http.Request) {
 		form, decodeErr := domain.NewFormByRequest(req)
 
 		if decodeErr != nil {
 			logger.ErrorContext(req.Context(), "decodeErr", slog.Any("error", decodeErr))
-			resp.BadRequestError(writer, decodeErr.Error())
+			resp.DecodeError(writer, decodeErr)
 
 			return
 		}
 
-		if form.IsGaugeType() {
-			st.GetMetrics().SetGaugeValue(form.ID, *form.Value)
-
-			resp.Send(req.Context(), writer, http.StatusOK, form)
+		if !form.IsGaugeType() && !form.IsCounterType() {
+			resp.BadRequestError(writer, "unknown metric type")
 
 			return
 		}
 
-		if form.IsCounterType() {
-			st.GetMetrics().AddCounterValue(form.ID, *form.Delta)
-
-			val, hasVal := st.GetMetrics().GetCounterValue(form.ID)
-			if !hasVal {
-				http.NotFound(writer, req)
-
-				return
-			}
+		apply := func() { applyForm(req.Context(), st, broker, form) }
 
-			form.Delta = &val
-
-			resp.Send(req.Context(), writer, http.StatusOK, form)
+		if admitErr := guard.Admit(req.Context(), apply, *form); admitErr != nil {
+			resp.BadRequestError(writer, admitErr.Error())
 
 			return
 		}
 
-		resp.BadRequestError(writer, "unknown metric type")
+		sendForm(writer, req, logger, resp, form)
 	}
 }
 
-func updateMetrics(st store.Store, logger *slog.Logger, resp *network.Response) http.HandlerFunc {
+func updateMetrics(
+	st store.Store,
+	broker *services.UpdateBroker,
+	guard *services.SeriesGuard,
+	logger *slog.Logger,
+	resp *network.Response,
+) http.HandlerFunc {
 	return func(writer http.ResponseWriter, req *http.Request) {
 		forms, decodeErr := domain.NewFormArrayByRequest(req)
 
 		if decodeErr != nil {
 			logger.ErrorContext(req.Context(), "decodeErr", slog.Any("error", decodeErr))
-			resp.BadRequestError(writer, decodeErr.Error())
+			resp.DecodeError(writer, decodeErr)
 
 			return
 		}
@@ -138,20 +257,93 @@ func updateMetrics(st store.Store, logger *slog.Logger, resp *network.Response)
 			return
 		}
 
-		for _, form := range forms {
-			if form.IsGaugeType() {
-				st.GetMetrics().SetGaugeValue(form.ID, *form.Value)
+		apply := func() {
+			for _, form := range forms {
+				applyForm(req.Context(), st, broker, &form)
 			}
+		}
 
-			if form.IsCounterType() {
-				st.GetMetrics().AddCounterValue(form.ID, *form.Delta)
-			}
+		if admitErr := guard.Admit(req.Context(), apply, forms...); admitErr != nil {
+			resp.BadRequestError(writer, admitErr.Error())
+
+			return
 		}
 
 		resp.Success(writer)
 	}
 }
 
+// applyForm stores the form value and publishes the resulting state to the stream subscribers.
+// For counters the form delta is replaced with the accumulated value.
+// Metadata sent along with the form is merged into the registry.
+func applyForm(
+	ctx context.Context,
+	st store.Store,
+	broker *services.UpdateBroker,
+	form *domain.MetricForm,
+) {
+	metrics := st.GetMetrics(domain.TenantFromContext(ctx))
+
+	switch {
+	case form.IsGaugeType():
+		metrics.SetGaugeValue(form.ID, *form.Value)
+	case form.IsCounterType():
+		metrics.AddCounterValue(form.ID, *form.Delta)
+
+		val, _ := metrics.GetCounterValue(form.ID)
+		form.Delta = &val
+	default:
+		return
+	}
+
+	if form.Meta != nil {
+		metrics.SetMeta(form.MType, form.ID, *form.Meta)
+	}
+
+	broker.Publish(ctx, *form)
+}
+
+// bodyMeta returns the metadata sent in the JSON body of a URL path update.
+// The body is optional, it's only taken into account when it describes the same series.
+func bodyMeta(req *http.Request, form domain.MetricForm) *domain.MetricMeta {
+	bodyForm, decodeErr := domain.NewFormByRequest(req)
+	if decodeErr != nil || bodyForm.ID != form.ID || bodyForm.MType != form.MType {
+		return nil
+	}
+
+	return bodyForm.Meta
+}
+
+// sendForm encodes the form in the format negotiated by the Accept header,
+// falling back to the one the request was sent in.
+func sendForm(
+	writer http.ResponseWriter,
+	req *http.Request,
+	logger *slog.Logger,
+	resp *network.Response,
+	form *domain.MetricForm,
+) {
+	codec := domain.NegotiateCodec(
+		req.Header.Get("Accept"),
+		domain.CodecByContentType(req.Header.Get("Content-Type")),
+	)
+
+	body, marshErr := codec.MarshalForm(form)
+	if marshErr != nil {
+		logger.ErrorContext(req.Context(), "encode form error", slog.Any("error", marshErr))
+		resp.ServerError(writer, http.StatusText(http.StatusInternalServerError))
+
+		return
+	}
+
+	resp.SendBody(req.Context(), writer, http.StatusOK, codec.ContentType(), body)
+}
+
+// requestMetrics returns the metric set of the tenant the request was resolved to.
+func requestMetrics(st store.Store, req *http.Request) *domain.Metrics {
+	return st.GetMetrics(domain.TenantFromContext(req.Context()))
+}
+
 func pingDB(st store.Store, resp *network.Response) http.HandlerFunc {
 	return func(writer http.ResponseWriter, _ *http.Request) {
 		storeType := st.GetStoreType()
@@ -172,23 +364,23 @@ func getMetric(st store.Store, logger *slog.Logger, resp *network.Response) http
 
 		if decodeErr != nil {
 			logger.ErrorContext(req.Context(), "decodeErr", slog.Any("error", decodeErr))
-			resp.BadRequestError(writer, decodeErr.Error())
+			resp.DecodeError(writer, decodeErr)
 
 			return
 		}
 
 		if form.IsGaugeType() {
-			value, _ := st.GetMetrics().GetGaugeValue(form.ID)
+			value, _ := requestMetrics(st, req).GetGaugeValue(form.ID)
 			form.Value = &value
-			resp.Send(req.Context(), writer, http.StatusOK, form)
+			sendForm(writer, req, logger, resp, form)
 
 			return
 		}
 
 		if form.IsCounterType() {
-			value, _ := st.GetMetrics().GetCounterValue(form.ID)
+			value, _ := requestMetrics(st, req).GetCounterValue(form.ID)
 			form.Delta = &value
-			resp.Send(req.Context(), writer, http.StatusOK, form)
+			sendForm(writer, req, logger, resp, form)
 
 			return
 		}
@@ -197,31 +389,63 @@ func getMetric(st store.Store, logger *slog.Logger, resp *network.Response) http
 	}
 }
 
-func updateCounter(st store.Store, resp *network.Response) http.HandlerFunc {
+func updateCounter(
+	st store.Store,
+	broker *services.UpdateBroker,
+	guard *services.SeriesGuard,
+	resp *network.Response,
+) http.HandlerFunc {
 	return func(writer http.ResponseWriter, req *http.Request) {
 		metric := req.PathValue(metricReqPathName)
 		value, convErr := strconv.ParseInt(req.PathValue(valueReqPathName), 10, 64)
 
 		if convErr != nil {
 			resp.BadRequestError(writer, convErr.Error())
+
+			return
 		}
 
-		st.GetMetrics().AddCounterValue(metric, value)
+		form := domain.MetricForm{ID: metric, MType: domain.MetricTypeCounter, Delta: &value}
+		form.Meta = bodyMeta(req, form)
+
+		apply := func() { applyForm(req.Context(), st, broker, &form) }
+
+		if admitErr := guard.Admit(req.Context(), apply, form); admitErr != nil {
+			resp.BadRequestError(writer, admitErr.Error())
+
+			return
+		}
 
 		resp.Success(writer)
 	}
 }
 
-func updateGauge(st store.Store, resp *network.Response) http.HandlerFunc {
+func updateGauge(
+	st store.Store,
+	broker *services.UpdateBroker,
+	guard *services.SeriesGuard,
+	resp *network.Response,
+) http.HandlerFunc {
 	return func(writer http.ResponseWriter, req *http.Request) {
 		metric := req.PathValue(metricReqPathName)
 		value, convErr := strconv.ParseFloat(req.PathValue(valueReqPathName), 64)
 
 		if convErr != nil {
 			resp.BadRequestError(writer, convErr.Error())
+
+			return
 		}
 
-		st.GetMetrics().SetGaugeValue(metric, value)
+		form := domain.MetricForm{ID: metric, MType: domain.MetricTypeGauge, Value: &value}
+		form.Meta = bodyMeta(req, form)
+
+		apply := func() { applyForm(req.Context(), st, broker, &form) }
+
+		if admitErr := guard.Admit(req.Context(), apply, form); admitErr != nil {
+			resp.BadRequestError(writer, admitErr.Error())
+
+			return
+		}
 
 		resp.Success(writer)
 	}
@@ -231,7 +455,7 @@ func getCounter(st store.Store, resp *network.Response) http.HandlerFunc {
 	return func(writer http.ResponseWriter, req *http.Request) {
 		metric := req.PathValue(metricReqPathName)
 
-		val, hasVal := st.GetMetrics().GetCounterValue(metric)
+		val, hasVal := requestMetrics(st, req).GetCounterValue(metric)
 
 		if !hasVal {
 			http.NotFound(writer, req)
@@ -247,7 +471,7 @@ func getGauge(st store.Store, resp *network.Response) http.HandlerFunc {
 	return func(writer http.ResponseWriter, req *http.Request) {
 		metric := req.PathValue(metricReqPathName)
 
-		val, hasVal := st.GetMetrics().GetGaugeValue(metric)
+		val, hasVal := requestMetrics(st, req).GetGaugeValue(metric)
 
 		if !hasVal {
 			http.NotFound(writer, req)
@@ -258,3 +482,35 @@ func getGauge(st store.Store, resp *network.Response) http.HandlerFunc {
 		resp.Send(req.Context(), writer, http.StatusOK, val)
 	}
 }
+
+func getCounterRate(
+	st store.Store,
+	conf *config.ServerConfig,
+	resp *network.Response,
+) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		metric := req.PathValue(metricReqPathName)
+
+		window := conf.GetRateWindowDuration()
+		if rawWindow := req.URL.Query().Get("window"); rawWindow != "" {
+			var parseErr error
+
+			window, parseErr = time.ParseDuration(rawWindow)
+			if parseErr != nil || window <= 0 || window > domain.CounterSampleRetention {
+				resp.BadRequestError(writer, "invalid window")
+
+				return
+			}
+		}
+
+		rate, hasVal := requestMetrics(st, req).GetCounterRate(metric, window, time.Now())
+
+		if !hasVal {
+			http.NotFound(writer, req)
+
+			return
+		}
+
+		resp.Send(req.Context(), writer, http.StatusOK, rate)
+	}
+}
@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"collector/internal/adapters/store"
+	"collector/internal/core/domain"
+	"collector/internal/core/services"
+	"collector/pkg/network"
+)
+
+type snapshotResult struct {
+	Mode   string `json:"mode"`
+	Series int    `json:"series"`
+}
+
+// exportSnapshot streams the metric set of the tenant,
+// it's compressed when the client asks for it in Accept-Encoding.
+func exportSnapshot(st store.Store, logger *slog.Logger) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		tenant := domain.TenantFromContext(req.Context())
+		snapshot := st.GetMetrics(tenant).Snapshot(tenant, time.Now().UTC())
+
+		writer.Header().Set("Content-Type", domain.ContentTypeJSON)
+		writer.Header().Set(
+			"Content-Disposition",
+			fmt.Sprintf(`attachment; filename="snapshot-%s.json"`, tenant),
+		)
+		writer.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(writer).Encode(snapshot); err != nil {
+			logger.ErrorContext(req.Context(), "write snapshot error", slog.Any("error", err))
+		}
+	}
+}
+
+// importSnapshot loads the snapshot into the tenant of the request and persists the result.
+// The merge mode keeps the series missing from the snapshot, the replace mode drops them.
+func importSnapshot(
+	st store.Store,
+	guard *services.SeriesGuard,
+	logger *slog.Logger,
+	resp *network.Response,
+) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		mode := req.URL.Query().Get("mode")
+		if mode == "" {
+			mode = domain.SnapshotModeMerge
+		}
+
+		if mode != domain.SnapshotModeMerge && mode != domain.SnapshotModeReplace {
+			resp.BadRequestError(writer, "unknown snapshot mode: "+mode)
+
+			return
+		}
+
+		var snapshot domain.Snapshot
+
+		if decodeErr := json.NewDecoder(req.Body).Decode(&snapshot); decodeErr != nil {
+			resp.DecodeError(writer, decodeErr)
+
+			return
+		}
+
+		replace := mode == domain.SnapshotModeReplace
+
+		tenant := domain.TenantFromContext(req.Context())
+
+		apply := func() {
+			if replace {
+				metrics := domain.NewMetrics()
+				metrics.Merge(&snapshot)
+				st.SetMetrics(tenant, metrics)
+			} else {
+				st.GetMetrics(tenant).Merge(&snapshot)
+			}
+		}
+
+		if admitErr := guard.AdmitSnapshot(req.Context(), &snapshot, replace, apply); admitErr != nil {
+			resp.BadRequestError(writer, admitErr.Error())
+
+			return
+		}
+
+		if saveErr := st.Save(req.Context()); saveErr != nil {
+			logger.ErrorContext(req.Context(), "save imported snapshot error", slog.Any("error", saveErr))
+			resp.ServerError(writer, http.StatusText(http.StatusInternalServerError))
+
+			return
+		}
+
+		logger.InfoContext(
+			req.Context(),
+			"snapshot imported",
+			slog.String("tenant", tenant),
+			slog.String("mode", mode),
+			slog.Int("series", snapshot.Len()),
+		)
+
+		resp.Send(req.Context(), writer, http.StatusOK, snapshotResult{Mode: mode, Series: snapshot.Len()})
+	}
+}
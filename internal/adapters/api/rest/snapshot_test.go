@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"collector/internal/adapters/keystore"
+	"collector/internal/adapters/store"
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/internal/core/services"
+	"collector/pkg/network"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestSnapshotRoutes_Auth(t *testing.T) {
+	tests := []struct {
+		name     string
+		auth     bool
+		apiKey   string
+		method   string
+		wantCode int
+		wantKept bool
+	}{
+		{name: "export without auth", method: http.MethodGet, wantCode: http.StatusNotFound, wantKept: true},
+		{name: "import without auth", method: http.MethodPost, wantCode: http.StatusNotFound, wantKept: true},
+		{
+			name:     "import without api key",
+			auth:     true,
+			method:   http.MethodPost,
+			wantCode: http.StatusUnauthorized,
+			wantKept: true,
+		},
+		{name: "import with admin key", auth: true, apiKey: "root", method: http.MethodPost, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.Default()
+			conf := &config.ServerConfig{AuthEnabled: tt.auth, AdminAPIKey: "root"}
+			resp := network.NewResponse(logger, conf)
+			keys := services.NewKeyService(logger, conf, keystore.NewMemoryKeyStore())
+
+			tenants := domain.NewTenants()
+			tenants.Get(domain.DefaultTenant).SetGaugeValue("Alloc", 1)
+			st := store.NewMemoryStorage(tenants)
+
+			router := chi.NewRouter()
+			router.Use(AuthMiddleware(keys, resp, logger))
+			registerSnapshotRoutes(st, keys, services.NewSeriesGuard(conf, st), router, logger, resp)
+
+			req := httptest.NewRequest(tt.method, "/snapshot?mode=replace", strings.NewReader("{}"))
+			if tt.apiKey != "" {
+				req.Header.Set(domain.APIKeyHeader, tt.apiKey)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+
+			if kept := st.GetMetrics(domain.DefaultTenant).Has(domain.MetricTypeGauge, "Alloc"); kept != tt.wantKept {
+				t.Errorf("series kept = %v, want %v", kept, tt.wantKept)
+			}
+		})
+	}
+}
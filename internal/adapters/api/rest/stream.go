@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"collector/internal/core/domain"
+	"collector/internal/core/services"
+	"collector/pkg/network"
+)
+
+const streamHeartbeatInterval = 15 * time.Second
+
+// streamMetrics pushes the accepted metric updates to the client as Server-Sent Events.
+// The stream ends when the client goes away or the broker drops it for falling behind.
+func streamMetrics(
+	broker *services.UpdateBroker,
+	logger *slog.Logger,
+	resp *network.Response,
+) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		filter, filterErr := newFilterByQuery(req)
+		if filterErr != nil {
+			resp.BadRequestError(writer, filterErr.Error())
+
+			return
+		}
+
+		controller := http.NewResponseController(writer)
+
+		writer.Header().Set("Content-Type", "text/event-stream")
+		writer.Header().Set("Cache-Control", "no-cache")
+		writer.Header().Set("Connection", "keep-alive")
+		writer.Header().Set("X-Accel-Buffering", "no")
+		writer.WriteHeader(http.StatusOK)
+
+		if flushErr := controller.Flush(); flushErr != nil {
+			logger.ErrorContext(req.Context(), "stream flush error", slog.Any("error", flushErr))
+
+			return
+		}
+
+		sub := broker.Subscribe(domain.TenantFromContext(req.Context()), filter)
+		defer broker.Unsubscribe(sub)
+
+		heartbeat := time.NewTicker(streamHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			var event string
+
+			select {
+			case <-req.Context().Done():
+				return
+			case form, ok := <-sub.Updates():
+				if !ok {
+					return
+				}
+
+				data, marshErr := json.Marshal(form)
+				if marshErr != nil {
+					logger.ErrorContext(
+						req.Context(),
+						"stream marshal error",
+						slog.Any("error", marshErr),
+					)
+
+					continue
+				}
+
+				event = fmt.Sprintf("event: metric\ndata: %s\n\n", data)
+			case <-heartbeat.C:
+				event = ": ping\n\n"
+			}
+
+			if _, writeErr := writer.Write([]byte(event)); writeErr != nil {
+				logger.WarnContext(req.Context(), "stream write error", slog.Any("error", writeErr))
+
+				return
+			}
+
+			if flushErr := controller.Flush(); flushErr != nil {
+				logger.WarnContext(req.Context(), "stream flush error", slog.Any("error", flushErr))
+
+				return
+			}
+		}
+	}
+}
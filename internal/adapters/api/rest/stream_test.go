@@ -0,0 +1,128 @@
+package rest
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"collector/internal/adapters/store"
+	"collector/internal/core/domain"
+	"collector/internal/core/services"
+	"collector/pkg/network"
+	"github.com/go-chi/chi/v5"
+)
+
+func newStreamTestRouter(t *testing.T) (*chi.Mux, *services.UpdateBroker) {
+	t.Helper()
+
+	logger := slog.Default()
+	conf := newTestConfig(t, "")
+	resp := network.NewResponse(logger, conf)
+	st := store.NewMemoryStorage(domain.NewTenants())
+	guard := services.NewSeriesGuard(conf, st)
+	broker := services.NewUpdateBroker(logger)
+	t.Cleanup(broker.Close)
+
+	router := chi.NewRouter()
+	router.Post("/update/", updateMetric(st, broker, guard, logger, resp))
+	router.Post("/updates/", updateMetrics(st, broker, guard, logger, resp))
+	router.Post("/update/counter/{metric}/{value}", updateCounter(st, broker, guard, resp))
+	router.Post("/update/gauge/{metric}/{value}", updateGauge(st, broker, guard, resp))
+	router.Get("/stream", streamMetrics(broker, logger, resp))
+
+	return router, broker
+}
+
+func TestUpdateHandlers_Publish(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		wantID string
+	}{
+		{name: "json update", path: "/update/", body: `{"id":"Alloc","type":"gauge","value":1}`, wantID: "Alloc"},
+		{name: "batch update", path: "/updates/", body: `[{"id":"Frees","type":"gauge","value":1}]`, wantID: "Frees"},
+		{name: "counter path update", path: "/update/counter/PollCount/1", wantID: "PollCount"},
+		{name: "gauge path update", path: "/update/gauge/HeapAlloc/1.5", wantID: "HeapAlloc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, broker := newStreamTestRouter(t)
+
+			sub := broker.Subscribe(domain.DefaultTenant, &domain.MetricFilter{})
+			defer broker.Unsubscribe(sub)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", domain.ContentTypeJSON)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+			}
+
+			select {
+			case form := <-sub.Updates():
+				if form.ID != tt.wantID {
+					t.Errorf("published %s, want %s", form.ID, tt.wantID)
+				}
+			default:
+				t.Fatal("update wasn't published")
+			}
+		})
+	}
+}
+
+func TestStreamMetrics(t *testing.T) {
+	router, broker := newStreamTestRouter(t)
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/stream?match=Alloc", nil)
+	if reqErr != nil {
+		t.Fatal(reqErr)
+	}
+
+	resp, respErr := srv.Client().Do(req)
+	if respErr != nil {
+		t.Fatal(respErr)
+	}
+	defer resp.Body.Close()
+
+	// The subscription starts after the headers are sent, so publish until the stream picks an update up.
+	go func() {
+		value := 1.0
+
+		for ctx.Err() == nil {
+			broker.Publish(ctx, domain.MetricForm{ID: "Frees", MType: domain.MetricTypeGauge, Value: &value})
+			broker.Publish(ctx, domain.MetricForm{ID: "Alloc", MType: domain.MetricTypeGauge, Value: &value})
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, isData := strings.CutPrefix(scanner.Text(), "data: ")
+		if !isData {
+			continue
+		}
+
+		if !strings.Contains(data, `"id":"Alloc"`) {
+			t.Fatalf("stream event = %s, want only Alloc updates", data)
+		}
+
+		return
+	}
+
+	t.Fatalf("stream ended without an event: %v", scanner.Err())
+}
@@ -0,0 +1,206 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"collector/internal/adapters/store"
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/internal/core/services"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsReadLimit = 4 << 20
+	// wsBatchLogLimit bounds the batch IDs remembered for deduplication across all connections.
+	wsBatchLogLimit = 100_000
+)
+
+var (
+	errBatchSign     = errors.New("batch signature mismatch")
+	errBatchUnsigned = errors.New("batch signature required")
+	errBatchReplay   = errors.New("batch signature timestamp and nonce required")
+	errBatchLimited  = errors.New("rate limited")
+)
+
+// ingestWebSocket accepts metric batches over a persistent websocket connection
+// and acknowledges every batch with its ID once the metrics are applied.
+// Every batch counts against the batch rate limit of the connected agent.
+// A batch with the ID of one already applied for the client is acknowledged without applying it again,
+// agents resend a batch with the same ID when the ack is lost.
+func ingestWebSocket(
+	st store.Store,
+	broker *services.UpdateBroker,
+	guard *services.SeriesGuard,
+	limits *RateLimits,
+	nonces *domain.NonceCache,
+	conf *config.ServerConfig,
+	logger *slog.Logger,
+) http.HandlerFunc {
+	upgrader := websocket.Upgrader{}
+	batches := domain.NewBatchLog(conf.GetReplayWindowDuration(), wsBatchLogLimit)
+
+	return func(writer http.ResponseWriter, req *http.Request) {
+		conn, upgradeErr := upgrader.Upgrade(writer, req, nil)
+		if upgradeErr != nil {
+			logger.WarnContext(req.Context(), "websocket upgrade error", slog.Any("error", upgradeErr))
+
+			return
+		}
+
+		defer func(conn *websocket.Conn) {
+			if closeErr := conn.Close(); closeErr != nil {
+				logger.WarnContext(
+					req.Context(),
+					"websocket close error",
+					slog.Any("error", closeErr),
+				)
+			}
+		}(conn)
+
+		conn.SetReadLimit(wsReadLimit)
+
+		limitKey := domain.ClientIDFromContext(req.Context())
+
+		for {
+			var batch domain.MetricBatch
+
+			if readErr := conn.ReadJSON(&batch); readErr != nil {
+				if !websocket.IsCloseError(readErr, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					logger.WarnContext(
+						req.Context(),
+						"websocket read error",
+						slog.Any("error", readErr),
+					)
+				}
+
+				return
+			}
+
+			ack := domain.BatchAck{ID: batch.ID, Status: domain.BatchStatusOK}
+			batchKey := limitKey + "/" + strconv.FormatUint(batch.ID, 10)
+
+			var (
+				forms    []domain.MetricForm
+				batchErr error
+			)
+
+			if ok, _ := limits.allow(limits.batch, limitKey, time.Now()); !ok {
+				batchErr = errBatchLimited
+			} else {
+				forms, batchErr = checkBatch(conf, nonces, &batch)
+			}
+
+			if batchErr == nil {
+				batchErr = applyBatchOnce(req.Context(), batches, batchKey, func() error {
+					return guard.Admit(req.Context(), func() {
+						for _, form := range forms {
+							applyForm(req.Context(), st, broker, &form)
+						}
+					}, forms...)
+				})
+			}
+
+			if batchErr != nil {
+				ack.Status = domain.BatchStatusError
+				ack.Error = batchErr.Error()
+			}
+
+			if writeErr := conn.WriteJSON(ack); writeErr != nil {
+				logger.WarnContext(
+					req.Context(),
+					"websocket write error",
+					slog.Any("error", writeErr),
+				)
+
+				return
+			}
+		}
+	}
+}
+
+// applyBatchOnce applies the batch unless it was applied already,
+// a batch resent while another connection still applies it waits for that apply to end.
+func applyBatchOnce(ctx context.Context, batches *domain.BatchLog, key string, apply func() error) error {
+	for {
+		begun, pending := batches.TryBegin(key, time.Now())
+		if begun {
+			break
+		}
+
+		if pending == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-pending:
+		}
+	}
+
+	if err := apply(); err != nil {
+		batches.Abort(key)
+
+		return err
+	}
+
+	batches.Commit(key, time.Now())
+
+	return nil
+}
+
+// checkBatch verifies the batch signature and decodes its metrics, rejecting the whole batch on any invalid form.
+// Signatures are checked the same way CheckSignMiddleware checks requests,
+// including the replay window and nonce for batches signed with a timestamp.
+func checkBatch(
+	conf *config.ServerConfig,
+	nonces *domain.NonceCache,
+	batch *domain.MetricBatch,
+) ([]domain.MetricForm, error) {
+	if keySet := conf.GetKeySet(); !keySet.IsEmpty() {
+		if batch.Hash == "" && conf.IsHashStrict() {
+			return nil, errBatchUnsigned
+		}
+
+		if batch.Hash != "" {
+			replayProtected := batch.Timestamp != "" || batch.Nonce != ""
+			if !replayProtected && conf.IsHashStrict() {
+				return nil, errBatchReplay
+			}
+
+			signed := string(batch.Metrics)
+			if replayProtected {
+				signed = domain.SignaturePayload(batch.Timestamp, batch.Nonce, batch.Metrics)
+			}
+
+			if !keySet.Verify(signed, batch.Hash, batch.KeyID) {
+				return nil, errBatchSign
+			}
+
+			if replayProtected {
+				if replayErr := checkReplay(nonces, batch.Timestamp, batch.Nonce); replayErr != nil {
+					return nil, replayErr
+				}
+			}
+		}
+	}
+
+	forms, decodeErr := batch.Forms()
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	for _, form := range forms {
+		if validateErr := form.Validate(); validateErr != nil {
+			return nil, validateErr
+		}
+	}
+
+	return forms, nil
+}
@@ -0,0 +1,136 @@
+package keystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/pkg/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type DBKeyStore struct {
+	poolConn *pgxpool.Pool
+}
+
+func NewDBKeyStore(ctx context.Context, conf *config.ServerConfig) (*DBKeyStore, error) {
+	poolConn, pollConnErr := db.NewPoolConn(ctx, conf.GetDSN())
+	if pollConnErr != nil {
+		return nil, fmt.Errorf("(db) get new poll connection error: %w", pollConnErr)
+	}
+
+	return &DBKeyStore{poolConn: poolConn}, nil
+}
+
+func (d *DBKeyStore) Find(ctx context.Context, hash string) (*domain.APIKey, error) {
+	row := d.poolConn.QueryRow(
+		ctx,
+		"SELECT id, hash, scopes, tenant, created_at FROM api_keys WHERE hash = $1",
+		hash,
+	)
+
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+
+		return nil, fmt.Errorf("(db) select api key error: %w", err)
+	}
+
+	return key, nil
+}
+
+func (d *DBKeyStore) List(ctx context.Context) ([]domain.APIKey, error) {
+	rows, queryErr := d.poolConn.Query(
+		ctx,
+		"SELECT id, hash, scopes, tenant, created_at FROM api_keys ORDER BY created_at",
+	)
+	if queryErr != nil {
+		return nil, fmt.Errorf("(db) select api keys error: %w", queryErr)
+	}
+
+	defer rows.Close()
+
+	var keys []domain.APIKey
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("(db) scan api key error: %w", err)
+		}
+
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("(db) read api keys error: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (d *DBKeyStore) Add(ctx context.Context, key *domain.APIKey) error {
+	_, err := d.poolConn.Exec(
+		ctx,
+		"INSERT INTO api_keys (id, hash, scopes, tenant, created_at) VALUES ($1, $2, $3, $4, $5)",
+		key.ID,
+		key.Hash,
+		scopeNames(key.Scopes),
+		key.Tenant,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("(db) insert api key error: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DBKeyStore) Delete(ctx context.Context, id string) error {
+	tag, err := d.poolConn.Exec(ctx, "DELETE FROM api_keys WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("(db) delete api key error: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (d *DBKeyStore) Close() error {
+	d.poolConn.Close()
+
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var (
+		key    domain.APIKey
+		scopes []string
+	)
+
+	if err := row.Scan(&key.ID, &key.Hash, &scopes, &key.Tenant, &key.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, domain.Scope(scope))
+	}
+
+	return &key, nil
+}
+
+func scopeNames(scopes []domain.Scope) []string {
+	names := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		names = append(names, string(scope))
+	}
+
+	return names
+}
@@ -0,0 +1,91 @@
+package keystore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"collector/internal/core/domain"
+)
+
+// FileKeyStore keeps the keys in memory and rewrites the JSON key file on every change.
+type FileKeyStore struct {
+	*MemoryKeyStore
+	path string
+}
+
+func NewFileKeyStore(filePath string) (*FileKeyStore, error) {
+	store := &FileKeyStore{MemoryKeyStore: NewMemoryKeyStore(), path: filePath}
+
+	data, readErr := os.ReadFile(filePath)
+	if errors.Is(readErr, os.ErrNotExist) {
+		return store, nil
+	}
+
+	if readErr != nil {
+		return nil, fmt.Errorf("(file) read api keys error: %w", readErr)
+	}
+
+	var keys []domain.APIKey
+	if decodeErr := json.Unmarshal(data, &keys); decodeErr != nil {
+		return nil, fmt.Errorf("(file) decode api keys error: %w", decodeErr)
+	}
+
+	for _, key := range keys {
+		if validateErr := key.Validate(); validateErr != nil {
+			return nil, fmt.Errorf("(file) invalid api key %s: %w", key.ID, validateErr)
+		}
+
+		store.keys[key.ID] = key
+	}
+
+	return store, nil
+}
+
+func (f *FileKeyStore) Add(ctx context.Context, key *domain.APIKey) error {
+	if err := f.MemoryKeyStore.Add(ctx, key); err != nil {
+		return err
+	}
+
+	return f.flush(ctx)
+}
+
+func (f *FileKeyStore) Delete(ctx context.Context, id string) error {
+	if err := f.MemoryKeyStore.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return f.flush(ctx)
+}
+
+func (f *FileKeyStore) flush(ctx context.Context) error {
+	keys, _ := f.List(ctx)
+
+	data, marshErr := json.MarshalIndent(keys, "", "  ")
+	if marshErr != nil {
+		return fmt.Errorf("(file) marshall api keys error: %w", marshErr)
+	}
+
+	tmpFile, tmpFileErr := os.CreateTemp(path.Dir(f.path), "collector-keys-*.bak")
+	if tmpFileErr != nil {
+		return fmt.Errorf("(file) create tmp keys file error: %w", tmpFileErr)
+	}
+
+	_, writeErr := tmpFile.Write(data)
+	closeErr := tmpFile.Close()
+
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		_ = os.Remove(tmpFile.Name())
+
+		return fmt.Errorf("(file) write tmp keys file error: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), f.path); err != nil {
+		return fmt.Errorf("(file) rename tmp keys file error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package keystore
+
+import (
+	"context"
+	"log/slog"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+)
+
+type KeyStore interface {
+	Find(ctx context.Context, hash string) (*domain.APIKey, error)
+	List(ctx context.Context) ([]domain.APIKey, error)
+	Add(ctx context.Context, key *domain.APIKey) error
+	Delete(ctx context.Context, id string) error
+	Close() error
+}
+
+// NewKeyStore prefers the key file when configured, then the database, and keeps the keys
+// in memory otherwise.
+func NewKeyStore(
+	ctx context.Context,
+	logger *slog.Logger,
+	conf *config.ServerConfig,
+) (KeyStore, error) {
+	if conf.GetAPIKeysFile() != "" {
+		return NewFileKeyStore(conf.GetAPIKeysFile())
+	}
+
+	if conf.GetDSN() != "" {
+		dbStore, dbErr := NewDBKeyStore(ctx, conf)
+		if dbErr == nil {
+			return dbStore, nil
+		}
+
+		logger.WarnContext(
+			ctx,
+			"NewKeyStore: failed to connect to database",
+			slog.Any("error", dbErr),
+		)
+	}
+
+	return NewMemoryKeyStore(), nil
+}
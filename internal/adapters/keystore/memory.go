@@ -0,0 +1,76 @@
+package keystore
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"collector/internal/core/domain"
+)
+
+type MemoryKeyStore struct {
+	keys map[string]domain.APIKey
+	mx   *sync.RWMutex
+}
+
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{
+		keys: make(map[string]domain.APIKey),
+		mx:   new(sync.RWMutex),
+	}
+}
+
+func (m *MemoryKeyStore) Find(_ context.Context, hash string) (*domain.APIKey, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	for _, key := range m.keys {
+		if key.Hash == hash {
+			return &key, nil
+		}
+	}
+
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+func (m *MemoryKeyStore) List(_ context.Context) ([]domain.APIKey, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	keys := make([]domain.APIKey, 0, len(m.keys))
+	for _, key := range m.keys {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (m *MemoryKeyStore) Add(_ context.Context, key *domain.APIKey) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.keys[key.ID] = *key
+
+	return nil
+}
+
+func (m *MemoryKeyStore) Delete(_ context.Context, id string) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	if _, ok := m.keys[id]; !ok {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	delete(m.keys, id)
+
+	return nil
+}
+
+func (m *MemoryKeyStore) Close() error {
+	return nil
+}
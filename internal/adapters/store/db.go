@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"sync"
 	"time"
 
 	"collector/internal/config"
@@ -16,15 +15,14 @@ import (
 type DBStorage struct {
 	logger   *slog.Logger
 	poolConn *pgxpool.Pool
-	metrics  *domain.Metrics
-	mx       *sync.RWMutex
+	tenants  *domain.Tenants
 }
 
 func NewDBStorage(
 	ctx context.Context,
 	logger *slog.Logger,
 	conf *config.ServerConfig,
-	metrics *domain.Metrics,
+	tenants *domain.Tenants,
 ) (*DBStorage, error) {
 	if conf.GetDSN() == "" {
 		return nil, fmt.Errorf("dsn is empty")
@@ -38,8 +36,7 @@ func NewDBStorage(
 	return &DBStorage{
 		logger:   logger,
 		poolConn: poolConn,
-		metrics:  metrics,
-		mx:       new(sync.RWMutex),
+		tenants:  tenants,
 	}, nil
 }
 
@@ -67,35 +64,57 @@ func (d *DBStorage) Save(ctx context.Context) error {
 		}
 	}()
 
-	_, truncErr := tx.Exec(ctx, "TRUNCATE TABLE counters; TRUNCATE TABLE gauges")
+	_, truncErr := tx.Exec(ctx, "TRUNCATE TABLE counters; TRUNCATE TABLE gauges; TRUNCATE TABLE metric_meta")
 	if truncErr != nil {
 		return fmt.Errorf("(db) truncate table error: %w", truncErr)
 	}
 
 	now := time.Now()
-	for k, v := range d.GetMetrics().GetGauges() {
-		_, txErr := tx.Exec(
-			ctx,
-			"INSERT INTO gauges (name, value, created_at) VALUES ($1, $2, $3)",
-			k,
-			v,
-			now,
-		)
-		if txErr != nil {
-			return fmt.Errorf("(db) transaction insert gauge error: %w", txErr)
+	for _, tenant := range d.GetTenants() {
+		metrics := d.GetMetrics(tenant)
+
+		for k, v := range metrics.GetGauges() {
+			_, txErr := tx.Exec(
+				ctx,
+				"INSERT INTO gauges (tenant, name, value, created_at) VALUES ($1, $2, $3, $4)",
+				tenant,
+				k,
+				v,
+				now,
+			)
+			if txErr != nil {
+				return fmt.Errorf("(db) transaction insert gauge error: %w", txErr)
+			}
 		}
-	}
 
-	for k, v := range d.GetMetrics().GetCounters() {
-		_, txErr := tx.Exec(
-			ctx,
-			"INSERT INTO counters (name, value, created_at) VALUES ($1, $2, $3)",
-			k,
-			v,
-			now,
-		)
-		if txErr != nil {
-			return fmt.Errorf("(db) transaction insert counter error: %w", txErr)
+		for k, v := range metrics.GetCounters() {
+			_, txErr := tx.Exec(
+				ctx,
+				"INSERT INTO counters (tenant, name, value, created_at) VALUES ($1, $2, $3, $4)",
+				tenant,
+				k,
+				v,
+				now,
+			)
+			if txErr != nil {
+				return fmt.Errorf("(db) transaction insert counter error: %w", txErr)
+			}
+		}
+
+		for _, form := range metrics.GetMetas() {
+			_, txErr := tx.Exec(
+				ctx,
+				"INSERT INTO metric_meta (tenant, type, name, unit, help, owner) VALUES ($1, $2, $3, $4, $5, $6)",
+				tenant,
+				form.MType,
+				form.ID,
+				form.Meta.Unit,
+				form.Meta.Help,
+				form.Meta.Owner,
+			)
+			if txErr != nil {
+				return fmt.Errorf("(db) transaction insert metric meta error: %w", txErr)
+			}
 		}
 	}
 
@@ -103,9 +122,16 @@ func (d *DBStorage) Save(ctx context.Context) error {
 }
 
 func (d *DBStorage) Restore(ctx context.Context) error {
-	metrics := domain.NewMetrics()
+	restored := make(map[string]*domain.Metrics)
+	tenantMetrics := func(tenant string) *domain.Metrics {
+		if _, ok := restored[tenant]; !ok {
+			restored[tenant] = domain.NewMetrics()
+		}
+
+		return restored[tenant]
+	}
 
-	queryG := "SELECT name, value FROM gauges"
+	queryG := "SELECT tenant, name, value FROM gauges"
 	gauges, gQueryErr := d.poolConn.Query(ctx, queryG)
 
 	if gQueryErr != nil {
@@ -115,21 +141,24 @@ func (d *DBStorage) Restore(ctx context.Context) error {
 	defer gauges.Close()
 
 	for gauges.Next() {
-		var gauge domain.Gauge
+		var (
+			tenant string
+			gauge  domain.Gauge
+		)
 
-		gTxErr := gauges.Scan(&gauge.Name, &gauge.Value)
+		gTxErr := gauges.Scan(&tenant, &gauge.Name, &gauge.Value)
 		if gTxErr != nil {
 			return fmt.Errorf("(db) scan gauge error: %w", gQueryErr)
 		}
 
-		metrics.Gauges[gauge.Name] = gauge.Value
+		tenantMetrics(tenant).Gauges[gauge.Name] = gauge.Value
 	}
 
 	if gReadErr := gauges.Err(); gReadErr != nil {
 		return fmt.Errorf("(db) read gauges error: %w", gReadErr)
 	}
 
-	queryC := "SELECT name, value FROM counters"
+	queryC := "SELECT tenant, name, value FROM counters"
 
 	counters, cQueryErr := d.poolConn.Query(ctx, queryC)
 	if cQueryErr != nil {
@@ -139,39 +168,82 @@ func (d *DBStorage) Restore(ctx context.Context) error {
 	defer counters.Close()
 
 	for counters.Next() {
-		var c domain.Counter
+		var (
+			tenant string
+			c      domain.Counter
+		)
 
-		cTxErr := counters.Scan(&c.Name, &c.Value)
+		cTxErr := counters.Scan(&tenant, &c.Name, &c.Value)
 		if cTxErr != nil {
 			return fmt.Errorf("(db) scan counter error: %w", cTxErr)
 		}
 
-		metrics.Counters[c.Name] = c.Value
+		tenantMetrics(tenant).Counters[c.Name] = c.Value
 	}
 
 	if cReadErr := counters.Err(); cReadErr != nil {
 		return fmt.Errorf("(db) read counters error: %w", cReadErr)
 	}
 
-	d.logger.DebugContext(ctx, "restored state", slog.Any("state", &metrics))
+	queryM := "SELECT tenant, type, name, unit, help, owner FROM metric_meta"
+
+	metas, mQueryErr := d.poolConn.Query(ctx, queryM)
+	if mQueryErr != nil {
+		return fmt.Errorf("(db) select metric meta error: %w", mQueryErr)
+	}
+
+	defer metas.Close()
 
-	d.SetMetrics(metrics)
+	for metas.Next() {
+		var (
+			tenant string
+			form   domain.MetricMetaForm
+		)
+
+		mTxErr := metas.Scan(&tenant, &form.MType, &form.ID, &form.Meta.Unit, &form.Meta.Help, &form.Meta.Owner)
+		if mTxErr != nil {
+			return fmt.Errorf("(db) scan metric meta error: %w", mTxErr)
+		}
+
+		tenantMetrics(tenant).SetMeta(form.MType, form.ID, form.Meta)
+	}
+
+	if mReadErr := metas.Err(); mReadErr != nil {
+		return fmt.Errorf("(db) read metric meta error: %w", mReadErr)
+	}
+
+	for tenant, metrics := range restored {
+		d.logger.DebugContext(
+			ctx,
+			"restored state",
+			slog.String("tenant", tenant),
+			slog.Any("state", metrics),
+		)
+
+		d.SetMetrics(tenant, metrics)
+	}
 
 	return nil
 }
 
-func (d *DBStorage) GetMetrics() *domain.Metrics {
-	d.mx.RLock()
-	defer d.mx.RUnlock()
+func (d *DBStorage) GetMetrics(tenant string) *domain.Metrics {
+	return d.tenants.Get(tenant)
+}
+
+func (d *DBStorage) LookupMetrics(tenant string) (*domain.Metrics, bool) {
+	return d.tenants.Lookup(tenant)
+}
 
-	return d.metrics
+func (d *DBStorage) CreateMetrics(tenant string, limit int) (*domain.Metrics, error) {
+	return d.tenants.Create(tenant, limit)
 }
 
-func (d *DBStorage) SetMetrics(metrics *domain.Metrics) {
-	d.mx.Lock()
-	defer d.mx.Unlock()
+func (d *DBStorage) SetMetrics(tenant string, metrics *domain.Metrics) {
+	d.tenants.Set(tenant, metrics)
+}
 
-	d.metrics = metrics
+func (d *DBStorage) GetTenants() []string {
+	return d.tenants.IDs()
 }
 
 func (d *DBStorage) Close() error {
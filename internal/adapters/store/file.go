@@ -4,28 +4,32 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path"
-	"sync"
+	"path/filepath"
+	"strings"
 
 	"collector/internal/config"
 	"collector/internal/core/domain"
 )
 
+// FileStorage keeps the default tenant snapshot at the configured path
+// and every other tenant next to it with the tenant ID appended as a suffix.
 type FileStorage struct {
 	logger  *slog.Logger
 	conf    *config.ServerConfig
-	metrics *domain.Metrics
-	mx      *sync.RWMutex
+	tenants *domain.Tenants
 }
 
 func NewFileStorage(
 	logger *slog.Logger,
 	conf *config.ServerConfig,
-	metrics *domain.Metrics,
+	tenants *domain.Tenants,
 ) (*FileStorage, error) {
 	if err := pingFS(logger); err != nil {
 		return nil, fmt.Errorf("(file) ping filesystem error: %w", err)
@@ -34,8 +38,7 @@ func NewFileStorage(
 	return &FileStorage{
 		logger:  logger,
 		conf:    conf,
-		metrics: metrics,
-		mx:      new(sync.RWMutex),
+		tenants: tenants,
 	}, nil
 }
 
@@ -43,7 +46,26 @@ func (f *FileStorage) GetStoreType() Type {
 	return FileStoreType
 }
 
+// Save writes a file per tenant, empty tenants other than the default one get no file.
 func (f *FileStorage) Save(ctx context.Context) error {
+	for _, tenant := range f.GetTenants() {
+		if tenant != domain.DefaultTenant && f.GetMetrics(tenant).Len() == 0 {
+			if err := os.Remove(f.tenantFilePath(tenant)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("(file) remove empty tenant %s error: %w", tenant, err)
+			}
+
+			continue
+		}
+
+		if err := f.saveTenant(ctx, tenant); err != nil {
+			return fmt.Errorf("(file) save tenant %s error: %w", tenant, err)
+		}
+	}
+
+	return nil
+}
+
+func (f *FileStorage) saveTenant(ctx context.Context, tenant string) error {
 	var tmpFileName string
 
 	err := func() error {
@@ -67,7 +89,7 @@ func (f *FileStorage) Save(ctx context.Context) error {
 			}
 		}(tmpFile)
 
-		data, marshErr := json.Marshal(f.GetMetrics())
+		data, marshErr := json.Marshal(f.GetMetrics(tenant))
 		if marshErr != nil {
 			return fmt.Errorf("(file) marshall metrics data error: %w", marshErr)
 		}
@@ -88,7 +110,7 @@ func (f *FileStorage) Save(ctx context.Context) error {
 		return nil
 	}
 
-	err = os.Rename(tmpFileName, f.conf.FileStoragePath)
+	err = os.Rename(tmpFileName, f.tenantFilePath(tenant))
 	if err != nil {
 		return fmt.Errorf("(file) tmp file rename error: %w", err)
 	}
@@ -97,21 +119,33 @@ func (f *FileStorage) Save(ctx context.Context) error {
 }
 
 func (f *FileStorage) Restore(ctx context.Context) error {
-	file, fileErr := os.Open(f.conf.FileStoragePath)
+	if err := f.restoreTenant(ctx, domain.DefaultTenant); err != nil {
+		return err
+	}
 
-	defer func(file *os.File) {
-		if file == nil {
-			return
+	tenantFiles, globErr := filepath.Glob(f.conf.FileStoragePath + ".*")
+	if globErr != nil {
+		return fmt.Errorf("(file) list tenant files error: %w", globErr)
+	}
+
+	for _, tenantFile := range tenantFiles {
+		tenant := strings.TrimPrefix(tenantFile, f.conf.FileStoragePath+".")
+		if domain.ValidateTenantID(tenant) != nil || tenant == domain.DefaultTenant {
+			continue
 		}
 
-		fileCloseErr := file.Close()
-		if fileCloseErr != nil {
-			f.logger.WarnContext(ctx, "(file) file close error", slog.Any("error", fileCloseErr))
+		if err := f.restoreTenant(ctx, tenant); err != nil {
+			return err
 		}
-	}(file)
+	}
+
+	return nil
+}
 
-	if file == nil {
-		f.logger.WarnContext(ctx, "(file) restore file doesn't exist")
+func (f *FileStorage) restoreTenant(ctx context.Context, tenant string) error {
+	file, fileErr := os.Open(f.tenantFilePath(tenant))
+	if errors.Is(fileErr, fs.ErrNotExist) {
+		f.logger.WarnContext(ctx, "(file) restore file doesn't exist", slog.String("tenant", tenant))
 
 		return nil
 	}
@@ -120,6 +154,12 @@ func (f *FileStorage) Restore(ctx context.Context) error {
 		return fmt.Errorf("(file) open restore file error: %w", fileErr)
 	}
 
+	defer func() {
+		if fileCloseErr := file.Close(); fileCloseErr != nil {
+			f.logger.WarnContext(ctx, "(file) file close error", slog.Any("error", fileCloseErr))
+		}
+	}()
+
 	dec := json.NewDecoder(bufio.NewReader(file))
 	lastState := domain.NewMetrics()
 
@@ -127,23 +167,37 @@ func (f *FileStorage) Restore(ctx context.Context) error {
 		return fmt.Errorf("(file) restore storage error: %w", err)
 	}
 
-	f.SetMetrics(lastState)
+	f.SetMetrics(tenant, lastState)
 
 	return nil
 }
 
-func (f *FileStorage) GetMetrics() *domain.Metrics {
-	f.mx.RLock()
-	defer f.mx.RUnlock()
+func (f *FileStorage) GetMetrics(tenant string) *domain.Metrics {
+	return f.tenants.Get(tenant)
+}
+
+func (f *FileStorage) LookupMetrics(tenant string) (*domain.Metrics, bool) {
+	return f.tenants.Lookup(tenant)
+}
+
+func (f *FileStorage) CreateMetrics(tenant string, limit int) (*domain.Metrics, error) {
+	return f.tenants.Create(tenant, limit)
+}
+
+func (f *FileStorage) SetMetrics(tenant string, metrics *domain.Metrics) {
+	f.tenants.Set(tenant, metrics)
+}
 
-	return f.metrics
+func (f *FileStorage) GetTenants() []string {
+	return f.tenants.IDs()
 }
 
-func (f *FileStorage) SetMetrics(metrics *domain.Metrics) {
-	f.mx.Lock()
-	defer f.mx.Unlock()
+func (f *FileStorage) tenantFilePath(tenant string) string {
+	if tenant == domain.DefaultTenant {
+		return f.conf.FileStoragePath
+	}
 
-	f.metrics = metrics
+	return f.conf.FileStoragePath + "." + tenant
 }
 
 func (f *FileStorage) Close() error {
@@ -2,20 +2,17 @@ package store
 
 import (
 	"context"
-	"sync"
 
 	"collector/internal/core/domain"
 )
 
 type MemStorage struct {
-	metrics *domain.Metrics
-	mx      *sync.RWMutex
+	tenants *domain.Tenants
 }
 
-func NewMemoryStorage(metrics *domain.Metrics) *MemStorage {
+func NewMemoryStorage(tenants *domain.Tenants) *MemStorage {
 	return &MemStorage{
-		metrics: metrics,
-		mx:      new(sync.RWMutex),
+		tenants: tenants,
 	}
 }
 
@@ -31,18 +28,24 @@ func (f *MemStorage) Restore(_ context.Context) error {
 	return nil
 }
 
-func (f *MemStorage) GetMetrics() *domain.Metrics {
-	f.mx.RLock()
-	defer f.mx.RUnlock()
+func (f *MemStorage) GetMetrics(tenant string) *domain.Metrics {
+	return f.tenants.Get(tenant)
+}
+
+func (f *MemStorage) LookupMetrics(tenant string) (*domain.Metrics, bool) {
+	return f.tenants.Lookup(tenant)
+}
 
-	return f.metrics
+func (f *MemStorage) CreateMetrics(tenant string, limit int) (*domain.Metrics, error) {
+	return f.tenants.Create(tenant, limit)
 }
 
-func (f *MemStorage) SetMetrics(metrics *domain.Metrics) {
-	f.mx.Lock()
-	defer f.mx.Unlock()
+func (f *MemStorage) SetMetrics(tenant string, metrics *domain.Metrics) {
+	f.tenants.Set(tenant, metrics)
+}
 
-	f.metrics = metrics
+func (f *MemStorage) GetTenants() []string {
+	return f.tenants.IDs()
 }
 
 func (f *MemStorage) Close() error {
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+)
+
+const (
+	fileURLScheme   = "file:"
+	memoryURLScheme = "memory:"
+)
+
+// Open creates the store addressed by the URL: file:<path>, postgres://<dsn> or memory:.
+func Open(ctx context.Context, logger *slog.Logger, url string, tenants *domain.Tenants) (Store, error) {
+	switch {
+	case strings.HasPrefix(url, fileURLScheme):
+		storagePath := strings.TrimPrefix(url, fileURLScheme)
+		if storagePath == "" {
+			return nil, fmt.Errorf("(file) empty storage path in %q", url)
+		}
+
+		fsStorage, fsStorageErr := NewFileStorage(
+			logger,
+			&config.ServerConfig{FileStoragePath: storagePath},
+			tenants,
+		)
+		if fsStorageErr != nil {
+			return nil, fsStorageErr
+		}
+
+		return fsStorage, nil
+	case strings.HasPrefix(url, "postgres://"), strings.HasPrefix(url, "postgresql://"):
+		dbStorage, dbErr := NewDBStorage(ctx, logger, &config.ServerConfig{DSN: url}, tenants)
+		if dbErr != nil {
+			return nil, dbErr
+		}
+
+		return dbStorage, nil
+	case url == memoryURLScheme:
+		return NewMemoryStorage(tenants), nil
+	default:
+		return nil, fmt.Errorf("unknown store url %q, expected file:<path>, postgres://<dsn> or memory:", url)
+	}
+}
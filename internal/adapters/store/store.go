@@ -17,8 +17,12 @@ const (
 )
 
 type Store interface {
-	GetMetrics() *domain.Metrics
-	SetMetrics(metrics *domain.Metrics)
+	// GetMetrics returns an empty set for unknown tenants, writes must go through CreateMetrics.
+	GetMetrics(tenant string) *domain.Metrics
+	LookupMetrics(tenant string) (*domain.Metrics, bool)
+	CreateMetrics(tenant string, limit int) (*domain.Metrics, error)
+	SetMetrics(tenant string, metrics *domain.Metrics)
+	GetTenants() []string
 	Save(ctx context.Context) error
 	Restore(ctx context.Context) error
 	Close() error
@@ -29,9 +33,9 @@ func NewStore(
 	ctx context.Context,
 	logger *slog.Logger,
 	conf *config.ServerConfig,
-	metrics *domain.Metrics,
+	tenants *domain.Tenants,
 ) Store {
-	dbStorage, dbErr := NewDBStorage(ctx, logger, conf, metrics)
+	dbStorage, dbErr := NewDBStorage(ctx, logger, conf, tenants)
 	if dbErr != nil {
 		logger.WarnContext(
 			ctx,
@@ -39,7 +43,7 @@ func NewStore(
 			slog.Any("error", dbErr),
 		)
 
-		fsStorage, fsStorageErr := NewFileStorage(logger, conf, metrics)
+		fsStorage, fsStorageErr := NewFileStorage(logger, conf, tenants)
 		if fsStorageErr != nil {
 			logger.WarnContext(
 				ctx,
@@ -47,7 +51,7 @@ func NewStore(
 				slog.Any("error", fsStorageErr),
 			)
 
-			return NewMemoryStorage(metrics)
+			return NewMemoryStorage(tenants)
 		}
 
 		return fsStorage
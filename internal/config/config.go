@@ -1,13 +1,23 @@
 package config
 
 import (
+	"crypto/rsa"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"collector/internal/core/domain"
+	"collector/pkg/compression"
+	"collector/pkg/encryption"
+	"collector/pkg/hashing"
+	"collector/pkg/tlsconfig"
 	"github.com/caarlos0/env/v11"
 )
 
@@ -15,25 +25,53 @@ const (
 	defaultReportIntervalSeconds = 10
 	defaultPollIntervalSeconds   = 2
 	defaultStoreIntervalSeconds  = 300
+	defaultRateWindowSeconds     = 60
+	defaultAlertIntervalSeconds  = 10
+	defaultReplayWindowSeconds   = 300
+	defaultMaxBodyBytes          = 1 << 20
+	defaultMaxDecompressedBytes  = 8 << 20
 	defaultRateLimit             = 5
+	defaultMaxTenants            = 100
 
 	AppTypeServer = AppType("server")
 	AppTypeAgent  = AppType("agent")
+
+	TransportHTTP      = "http"
+	TransportWebSocket = "ws"
 )
 
 type (
 	AppType    string
 	BaseConfig struct {
-		AppType  AppType
-		LogLevel string `env:"LOG_LEVEL"`
-		Address  string `env:"ADDRESS"`
-		HashKey  string `env:"KEY"`
+		AppType   AppType
+		LogLevel  string `env:"LOG_LEVEL"`
+		Address   string `env:"ADDRESS"`
+		HashKey   string `env:"KEY"`
+		HashKeyID string `env:"KEY_ID"`
+		CryptoKey string `env:"CRYPTO_KEY"`
+		TLSCert   string `env:"TLS_CERT"`
+		TLSKey    string `env:"TLS_KEY"`
+		// TLSMinVersion is "1.2" or "1.3".
+		TLSMinVersion string `env:"TLS_MIN_VERSION"`
 	}
 	AgentConfig struct {
 		BaseConfig
-		ReportInterval int `env:"REPORT_INTERVAL"`
-		PollInterval   int `env:"POLL_INTERVAL"`
-		RateLimit      int `env:"RATE_LIMIT"`
+		ReportInterval int    `env:"REPORT_INTERVAL"`
+		PollInterval   int    `env:"POLL_INTERVAL"`
+		RateLimit      int    `env:"RATE_LIMIT"`
+		Transport      string `env:"TRANSPORT"`
+		Tenant         string `env:"TENANT"`
+		APIKey         string `env:"API_KEY"`
+		TLS            bool   `env:"TLS"`
+		TLSCA          string `env:"TLS_CA"`
+		SendMeta       bool   `env:"SEND_META"`
+		Encoding       string `env:"ENCODING"`
+		Compress       string `env:"COMPRESS"`
+		HashKeys       string `env:"KEYS"`
+		codec          domain.Codec
+		keySet         *hashing.KeySet
+		publicKey      *rsa.PublicKey
+		tlsConfig      *tls.Config
 	}
 	ServerConfig struct {
 		BaseConfig
@@ -41,32 +79,146 @@ type (
 		DSN             string `env:"DATABASE_DSN"`
 		StoreInterval   int    `env:"STORE_INTERVAL"`
 		Restore         bool   `env:"RESTORE"`
+		RateWindow      int    `env:"RATE_WINDOW"`
+		AlertRulesPath  string `env:"ALERT_RULES"`
+		AlertInterval   int    `env:"ALERT_INTERVAL"`
+		AlertWebhooks   []string
+		AlertWebhookKey string `env:"ALERT_WEBHOOK_KEY"`
+		AuthEnabled     bool   `env:"AUTH_ENABLED"`
+		APIKeysFile     string `env:"API_KEYS_FILE"`
+		AdminAPIKey     string `env:"ADMIN_API_KEY"`
+		HashStrict      bool   `env:"HASH_STRICT"`
+		HashKeys        string `env:"KEYS"`
+		ReplayWindow    int    `env:"REPLAY_WINDOW"`
+		keySet          *hashing.KeySet
+		TLSClientCA     string `env:"TLS_CLIENT_CA"`
+		// TrustedSubnets restrict the write routes, TrustedReadSubnets the read ones.
+		// X-Real-IP is only honored from the TrustedProxies.
+		TrustedSubnets     []netip.Prefix
+		TrustedReadSubnets []netip.Prefix
+		TrustedProxies     []netip.Prefix
+		// Rate limits in requests per second, zero disables the limit.
+		LimitUpdateRPS int `env:"LIMIT_UPDATE_RPS"`
+		LimitBatchRPS  int `env:"LIMIT_BATCH_RPS"`
+		LimitGlobalRPS int `env:"LIMIT_GLOBAL_RPS"`
+		LimitBurst     int `env:"LIMIT_BURST"`
+		// MaxBodyBytes limits the body as received, MaxDecompressedBytes after decompression.
+		MaxBodyBytes         int    `env:"MAX_BODY_BYTES"`
+		MaxDecompressedBytes int    `env:"MAX_DECOMPRESSED_BYTES"`
+		MetricNamePattern    string `env:"METRIC_NAME_PATTERN"`
+		MetricNameMaxLen     int    `env:"METRIC_NAME_MAX_LEN"`
+		// MaxSeries caps the series of all tenants, MaxSeriesPerAgent the series created by one agent.
+		// MaxTenants caps the tenants, which are created by the first write to them.
+		MaxSeries         int `env:"MAX_SERIES"`
+		MaxSeriesPerAgent int `env:"MAX_SERIES_PER_AGENT"`
+		MaxTenants        int `env:"MAX_TENANTS"`
+		namePolicy        *domain.NamePolicy
+		privateKey        *rsa.PrivateKey
+		tlsConfig         *tls.Config
 	}
 	EnvContainer struct {
-		AppType         AppType
-		LogLevel        string `env:"LOG_LEVEL"`
-		Address         string `env:"ADDRESS"`
-		HashKey         string `env:"KEY"`
-		ReportInterval  int    `env:"REPORT_INTERVAL"`
-		PollInterval    int    `env:"POLL_INTERVAL"`
-		RateLimit       int    `env:"RATE_LIMIT"`
-		FileStoragePath string `env:"FILE_STORAGE_PATH"`
-		DSN             string `env:"DATABASE_DSN"`
-		StoreInterval   int    `env:"STORE_INTERVAL"`
-		Restore         bool   `env:"RESTORE"`
+		AppType              AppType
+		LogLevel             string `env:"LOG_LEVEL"`
+		Address              string `env:"ADDRESS"`
+		HashKey              string `env:"KEY"`
+		ReportInterval       int    `env:"REPORT_INTERVAL"`
+		PollInterval         int    `env:"POLL_INTERVAL"`
+		RateLimit            int    `env:"RATE_LIMIT"`
+		FileStoragePath      string `env:"FILE_STORAGE_PATH"`
+		DSN                  string `env:"DATABASE_DSN"`
+		StoreInterval        int    `env:"STORE_INTERVAL"`
+		Restore              bool   `env:"RESTORE"`
+		RateWindow           int    `env:"RATE_WINDOW"`
+		Transport            string `env:"TRANSPORT"`
+		AlertRulesPath       string `env:"ALERT_RULES"`
+		AlertInterval        int    `env:"ALERT_INTERVAL"`
+		AlertWebhooks        string `env:"ALERT_WEBHOOKS"`
+		AlertWebhookKey      string `env:"ALERT_WEBHOOK_KEY"`
+		Tenant               string `env:"TENANT"`
+		APIKey               string `env:"API_KEY"`
+		AuthEnabled          bool   `env:"AUTH_ENABLED"`
+		APIKeysFile          string `env:"API_KEYS_FILE"`
+		AdminAPIKey          string `env:"ADMIN_API_KEY"`
+		HashStrict           bool   `env:"HASH_STRICT"`
+		HashKeyID            string `env:"KEY_ID"`
+		HashKeys             string `env:"KEYS"`
+		ReplayWindow         int    `env:"REPLAY_WINDOW"`
+		CryptoKey            string `env:"CRYPTO_KEY"`
+		TLSCert              string `env:"TLS_CERT"`
+		TLSKey               string `env:"TLS_KEY"`
+		TLSMinVersion        string `env:"TLS_MIN_VERSION"`
+		TLSClientCA          string `env:"TLS_CLIENT_CA"`
+		TLS                  bool   `env:"TLS"`
+		TLSCA                string `env:"TLS_CA"`
+		SendMeta             bool   `env:"SEND_META"`
+		Encoding             string `env:"ENCODING"`
+		Compress             string `env:"COMPRESS"`
+		TrustedSubnet        string `env:"TRUSTED_SUBNET"`
+		TrustedReadSubnet    string `env:"TRUSTED_READ_SUBNET"`
+		TrustedProxy         string `env:"TRUSTED_PROXY"`
+		LimitUpdateRPS       int    `env:"LIMIT_UPDATE_RPS"`
+		LimitBatchRPS        int    `env:"LIMIT_BATCH_RPS"`
+		LimitGlobalRPS       int    `env:"LIMIT_GLOBAL_RPS"`
+		LimitBurst           int    `env:"LIMIT_BURST"`
+		MaxBodyBytes         int    `env:"MAX_BODY_BYTES"`
+		MaxDecompressedBytes int    `env:"MAX_DECOMPRESSED_BYTES"`
+		MetricNamePattern    string `env:"METRIC_NAME_PATTERN"`
+		MetricNameMaxLen     int    `env:"METRIC_NAME_MAX_LEN"`
+		MaxSeries            int    `env:"MAX_SERIES"`
+		MaxSeriesPerAgent    int    `env:"MAX_SERIES_PER_AGENT"`
+		MaxTenants           int    `env:"MAX_TENANTS"`
 	}
 	FlagContainer struct {
-		AppType         AppType
-		LogLevel        string
-		Address         string
-		HashKey         string
-		ReportInterval  int
-		PollInterval    int
-		RateLimit       int
-		FileStoragePath string
-		DSN             string
-		StoreInterval   int
-		Restore         bool
+		AppType              AppType
+		LogLevel             string
+		Address              string
+		HashKey              string
+		ReportInterval       int
+		PollInterval         int
+		RateLimit            int
+		FileStoragePath      string
+		DSN                  string
+		StoreInterval        int
+		Restore              bool
+		RateWindow           int
+		Transport            string
+		AlertRulesPath       string
+		AlertInterval        int
+		AlertWebhooks        string
+		AlertWebhookKey      string
+		Tenant               string
+		APIKey               string
+		AuthEnabled          bool
+		APIKeysFile          string
+		AdminAPIKey          string
+		HashStrict           bool
+		HashKeyID            string
+		HashKeys             string
+		ReplayWindow         int
+		CryptoKey            string
+		TLSCert              string
+		TLSKey               string
+		TLSMinVersion        string
+		TLSClientCA          string
+		TLS                  bool
+		TLSCA                string
+		SendMeta             bool
+		Encoding             string
+		Compress             string
+		TrustedSubnet        string
+		TrustedReadSubnet    string
+		TrustedProxy         string
+		LimitUpdateRPS       int
+		LimitBatchRPS        int
+		LimitGlobalRPS       int
+		LimitBurst           int
+		MaxBodyBytes         int
+		MaxDecompressedBytes int
+		MetricNamePattern    string
+		MetricNameMaxLen     int
+		MaxSeries            int
+		MaxSeriesPerAgent    int
+		MaxTenants           int
 	}
 )
 
@@ -76,16 +228,106 @@ func (fc *FlagContainer) Parse() {
 		flag.BoolVar(&fc.Restore, "r", true, "restore previous data")
 		flag.StringVar(&fc.DSN, "d", "", "postgres DSN")
 		flag.IntVar(&fc.StoreInterval, "i", defaultStoreIntervalSeconds, "store interval")
+		flag.IntVar(&fc.RateWindow, "w", defaultRateWindowSeconds, "counter rate window")
+		flag.StringVar(&fc.AlertRulesPath, "alert_rules", "", "alert rules file path")
+		flag.IntVar(
+			&fc.AlertInterval,
+			"alert_interval",
+			defaultAlertIntervalSeconds,
+			"alert rules evaluation interval",
+		)
+		flag.StringVar(
+			&fc.AlertWebhooks,
+			"alert_webhooks",
+			"",
+			"comma-separated alert webhook URLs, <tenant>=<url> for a tenant other than the default one",
+		)
+		flag.StringVar(&fc.AlertWebhookKey, "alert_webhook_key", "", "alert webhook signing key")
+		flag.BoolVar(&fc.AuthEnabled, "auth", false, "require api keys")
+		flag.StringVar(&fc.APIKeysFile, "api_keys_file", "", "api keys file path")
+		flag.StringVar(&fc.AdminAPIKey, "admin_api_key", "", "bootstrap admin api key")
+		flag.BoolVar(&fc.HashStrict, "hash_strict", false, "reject unsigned requests and signatures without timestamp and nonce")
+		flag.StringVar(&fc.HashKeys, "keys", "", "comma-separated id:key pairs of active hash keys")
+		flag.StringVar(&fc.TLSClientCA, "tls_client_ca", "", "CA file verifying agent certificates")
+		flag.StringVar(&fc.TrustedSubnet, "t", "", "comma-separated CIDRs allowed to write metrics")
+		flag.StringVar(
+			&fc.TrustedReadSubnet,
+			"trusted_read_subnet",
+			"",
+			"comma-separated CIDRs allowed to read metrics",
+		)
+		flag.StringVar(
+			&fc.TrustedProxy,
+			"trusted_proxy",
+			"",
+			"comma-separated CIDRs of the proxies whose X-Real-IP header is trusted",
+		)
+		flag.IntVar(&fc.LimitUpdateRPS, "limit_update_rps", 0, "single updates per second per agent")
+		flag.IntVar(&fc.LimitBatchRPS, "limit_batch_rps", 0, "batch updates per second per agent")
+		flag.IntVar(&fc.LimitGlobalRPS, "limit_global_rps", 0, "updates per second for all agents")
+		flag.IntVar(&fc.LimitBurst, "limit_burst", 0, "rate limit burst")
+		flag.IntVar(&fc.MaxBodyBytes, "max_body_bytes", defaultMaxBodyBytes, "max request body size")
+		flag.IntVar(
+			&fc.MaxDecompressedBytes,
+			"max_decompressed_bytes",
+			defaultMaxDecompressedBytes,
+			"max request body size after decompression",
+		)
+		flag.StringVar(
+			&fc.MetricNamePattern,
+			"metric_name_pattern",
+			domain.DefaultMetricNamePattern,
+			"regex metric names must match",
+		)
+		flag.IntVar(
+			&fc.MetricNameMaxLen,
+			"metric_name_max_len",
+			domain.DefaultMetricNameMaxLen,
+			"max metric name length",
+		)
+		flag.IntVar(&fc.MaxSeries, "max_series", 0, "max number of series, 0 is unlimited")
+		flag.IntVar(
+			&fc.MaxSeriesPerAgent,
+			"max_series_per_agent",
+			0,
+			"max number of series created by one agent, 0 is unlimited",
+		)
+		flag.IntVar(&fc.MaxTenants, "max_tenants", defaultMaxTenants, "max number of tenants, 0 is unlimited")
+		flag.IntVar(
+			&fc.ReplayWindow,
+			"replay_window",
+			defaultReplayWindowSeconds,
+			"allowed clock skew of signed requests",
+		)
 	}
 	if fc.AppType == AppTypeAgent {
 		flag.IntVar(&fc.PollInterval, "p", defaultPollIntervalSeconds, "poll interval")
 		flag.IntVar(&fc.ReportInterval, "r", defaultReportIntervalSeconds, "report interval")
 		flag.IntVar(&fc.RateLimit, "l", defaultRateLimit, "rate limit")
+		flag.StringVar(&fc.Transport, "transport", TransportHTTP, "transport: http or ws")
+		flag.StringVar(&fc.Tenant, "tenant", "", "tenant to report metrics to")
+		flag.StringVar(&fc.APIKey, "api_key", "", "api key")
+		flag.BoolVar(&fc.TLS, "tls", false, "connect to the server over tls")
+		flag.StringVar(&fc.TLSCA, "tls_ca", "", "CA file the server certificate is pinned to")
+		flag.BoolVar(&fc.SendMeta, "send_meta", false, "send units and help of the metrics")
+		flag.StringVar(&fc.Encoding, "encoding", domain.EncodingJSON, "metric body encoding: json, protobuf or msgpack")
+		flag.StringVar(&fc.Compress, "compress", "", "body compression: zstd, br or gzip, none when empty")
+		flag.StringVar(&fc.HashKeys, "keys", "", "comma-separated id:key pairs response signatures are verified with")
 	}
 
 	flag.StringVar(&fc.LogLevel, "log_level", "debug", "log level")
 	flag.StringVar(&fc.Address, "a", "localhost:8080", "server address")
 	flag.StringVar(&fc.HashKey, "k", "", "hash key")
+	flag.StringVar(&fc.HashKeyID, "key_id", "", "hash key id")
+	flag.StringVar(
+		&fc.CryptoKey,
+		"crypto_key",
+		"",
+		"path to the server public key for the agent or the private key for the server",
+	)
+	flag.StringVar(&fc.TLSCert, "tls_cert", "", "tls certificate file")
+	flag.StringVar(&fc.TLSKey, "tls_key", "", "tls private key file")
+	flag.StringVar(&fc.TLSMinVersion, "tls_min_version", "1.2", "minimal tls version: 1.2 or 1.3")
 
 	flag.Parse()
 
@@ -101,6 +343,42 @@ func (fc *FlagContainer) Parse() {
 		slog.String("FILE_STORAGE_PATH", fc.FileStoragePath),
 		slog.Bool("RESTORE", fc.Restore),
 		slog.String("DATABASE_DSN", fc.DSN),
+		slog.Int("RATE_WINDOW", fc.RateWindow),
+		slog.String("TRANSPORT", fc.Transport),
+		slog.String("ALERT_RULES", fc.AlertRulesPath),
+		slog.Int("ALERT_INTERVAL", fc.AlertInterval),
+		slog.Any("ALERT_WEBHOOKS", redactWebhooks(splitList(fc.AlertWebhooks))),
+		slog.Bool("ALERT_WEBHOOK_KEY", fc.AlertWebhookKey != ""),
+		slog.String("TENANT", fc.Tenant),
+		slog.Bool("AUTH_ENABLED", fc.AuthEnabled),
+		slog.Bool("HASH_STRICT", fc.HashStrict),
+		slog.String("KEY_ID", fc.HashKeyID),
+		slog.Int("REPLAY_WINDOW", fc.ReplayWindow),
+		slog.String("CRYPTO_KEY", fc.CryptoKey),
+		slog.String("TLS_CERT", fc.TLSCert),
+		slog.String("TLS_KEY", fc.TLSKey),
+		slog.String("TLS_MIN_VERSION", fc.TLSMinVersion),
+		slog.String("TLS_CLIENT_CA", fc.TLSClientCA),
+		slog.Bool("TLS", fc.TLS),
+		slog.String("TLS_CA", fc.TLSCA),
+		slog.Bool("SEND_META", fc.SendMeta),
+		slog.String("ENCODING", fc.Encoding),
+		slog.String("COMPRESS", fc.Compress),
+		slog.String("TRUSTED_SUBNET", fc.TrustedSubnet),
+		slog.String("TRUSTED_READ_SUBNET", fc.TrustedReadSubnet),
+		slog.String("TRUSTED_PROXY", fc.TrustedProxy),
+		slog.Int("LIMIT_UPDATE_RPS", fc.LimitUpdateRPS),
+		slog.Int("LIMIT_BATCH_RPS", fc.LimitBatchRPS),
+		slog.Int("LIMIT_GLOBAL_RPS", fc.LimitGlobalRPS),
+		slog.Int("LIMIT_BURST", fc.LimitBurst),
+		slog.Int("MAX_BODY_BYTES", fc.MaxBodyBytes),
+		slog.Int("MAX_DECOMPRESSED_BYTES", fc.MaxDecompressedBytes),
+		slog.String("METRIC_NAME_PATTERN", fc.MetricNamePattern),
+		slog.Int("METRIC_NAME_MAX_LEN", fc.MetricNameMaxLen),
+		slog.Int("MAX_SERIES", fc.MaxSeries),
+		slog.Int("MAX_SERIES_PER_AGENT", fc.MaxSeriesPerAgent),
+		slog.Int("MAX_TENANTS", fc.MaxTenants),
+		slog.String("API_KEYS_FILE", fc.APIKeysFile),
 	)
 }
 
@@ -117,6 +395,42 @@ func (ec *EnvContainer) Parse() {
 		slog.String("FILE_STORAGE_PATH", os.Getenv("FILE_STORAGE_PATH")),
 		slog.String("RESTORE", os.Getenv("RESTORE")),
 		slog.String("DATABASE_DSN", os.Getenv("DATABASE_DSN")),
+		slog.String("RATE_WINDOW", os.Getenv("RATE_WINDOW")),
+		slog.String("TRANSPORT", os.Getenv("TRANSPORT")),
+		slog.String("ALERT_RULES", os.Getenv("ALERT_RULES")),
+		slog.String("ALERT_INTERVAL", os.Getenv("ALERT_INTERVAL")),
+		slog.Any("ALERT_WEBHOOKS", redactWebhooks(splitList(os.Getenv("ALERT_WEBHOOKS")))),
+		slog.Bool("ALERT_WEBHOOK_KEY", os.Getenv("ALERT_WEBHOOK_KEY") != ""),
+		slog.String("TENANT", os.Getenv("TENANT")),
+		slog.String("AUTH_ENABLED", os.Getenv("AUTH_ENABLED")),
+		slog.String("HASH_STRICT", os.Getenv("HASH_STRICT")),
+		slog.String("KEY_ID", os.Getenv("KEY_ID")),
+		slog.String("REPLAY_WINDOW", os.Getenv("REPLAY_WINDOW")),
+		slog.String("CRYPTO_KEY", os.Getenv("CRYPTO_KEY")),
+		slog.String("TLS_CERT", os.Getenv("TLS_CERT")),
+		slog.String("TLS_KEY", os.Getenv("TLS_KEY")),
+		slog.String("TLS_MIN_VERSION", os.Getenv("TLS_MIN_VERSION")),
+		slog.String("TLS_CLIENT_CA", os.Getenv("TLS_CLIENT_CA")),
+		slog.String("TLS", os.Getenv("TLS")),
+		slog.String("TLS_CA", os.Getenv("TLS_CA")),
+		slog.String("SEND_META", os.Getenv("SEND_META")),
+		slog.String("ENCODING", os.Getenv("ENCODING")),
+		slog.String("COMPRESS", os.Getenv("COMPRESS")),
+		slog.String("TRUSTED_SUBNET", os.Getenv("TRUSTED_SUBNET")),
+		slog.String("TRUSTED_READ_SUBNET", os.Getenv("TRUSTED_READ_SUBNET")),
+		slog.String("TRUSTED_PROXY", os.Getenv("TRUSTED_PROXY")),
+		slog.String("LIMIT_UPDATE_RPS", os.Getenv("LIMIT_UPDATE_RPS")),
+		slog.String("LIMIT_BATCH_RPS", os.Getenv("LIMIT_BATCH_RPS")),
+		slog.String("LIMIT_GLOBAL_RPS", os.Getenv("LIMIT_GLOBAL_RPS")),
+		slog.String("LIMIT_BURST", os.Getenv("LIMIT_BURST")),
+		slog.String("MAX_BODY_BYTES", os.Getenv("MAX_BODY_BYTES")),
+		slog.String("MAX_DECOMPRESSED_BYTES", os.Getenv("MAX_DECOMPRESSED_BYTES")),
+		slog.String("METRIC_NAME_PATTERN", os.Getenv("METRIC_NAME_PATTERN")),
+		slog.String("METRIC_NAME_MAX_LEN", os.Getenv("METRIC_NAME_MAX_LEN")),
+		slog.String("MAX_SERIES", os.Getenv("MAX_SERIES")),
+		slog.String("MAX_SERIES_PER_AGENT", os.Getenv("MAX_SERIES_PER_AGENT")),
+		slog.String("MAX_TENANTS", os.Getenv("MAX_TENANTS")),
+		slog.String("API_KEYS_FILE", os.Getenv("API_KEYS_FILE")),
 	)
 
 	err := env.Parse(ec)
@@ -149,6 +463,36 @@ func buildBaseConfig(appType AppType, fc *FlagContainer, ec *EnvContainer) BaseC
 		conf.HashKey = fc.HashKey
 	}
 
+	if ec.HashKeyID != "" {
+		conf.HashKeyID = ec.HashKeyID
+	} else {
+		conf.HashKeyID = fc.HashKeyID
+	}
+
+	if ec.CryptoKey != "" {
+		conf.CryptoKey = ec.CryptoKey
+	} else {
+		conf.CryptoKey = fc.CryptoKey
+	}
+
+	if ec.TLSCert != "" {
+		conf.TLSCert = ec.TLSCert
+	} else {
+		conf.TLSCert = fc.TLSCert
+	}
+
+	if ec.TLSKey != "" {
+		conf.TLSKey = ec.TLSKey
+	} else {
+		conf.TLSKey = fc.TLSKey
+	}
+
+	if ec.TLSMinVersion != "" {
+		conf.TLSMinVersion = ec.TLSMinVersion
+	} else {
+		conf.TLSMinVersion = fc.TLSMinVersion
+	}
+
 	return conf
 }
 
@@ -170,6 +514,89 @@ func NewAgentConfig(fc *FlagContainer, ec *EnvContainer) (*AgentConfig, error) {
 	} else {
 		conf.RateLimit = fc.RateLimit
 	}
+	if ec.Transport != "" {
+		conf.Transport = ec.Transport
+	} else {
+		conf.Transport = fc.Transport
+	}
+	if conf.Transport != TransportHTTP && conf.Transport != TransportWebSocket {
+		return nil, fmt.Errorf("unknown TRANSPORT: %s", conf.Transport)
+	}
+	if ec.Tenant != "" {
+		conf.Tenant = ec.Tenant
+	} else {
+		conf.Tenant = fc.Tenant
+	}
+	if ec.APIKey != "" {
+		conf.APIKey = ec.APIKey
+	} else {
+		conf.APIKey = fc.APIKey
+	}
+	conf.TLS = ec.TLS || fc.TLS
+	if ec.TLSCA != "" {
+		conf.TLSCA = ec.TLSCA
+	} else {
+		conf.TLSCA = fc.TLSCA
+	}
+	conf.SendMeta = ec.SendMeta || fc.SendMeta
+	if ec.Encoding != "" {
+		conf.Encoding = ec.Encoding
+	} else {
+		conf.Encoding = fc.Encoding
+	}
+	codec, codecErr := domain.CodecByName(conf.Encoding)
+	if codecErr != nil {
+		return nil, fmt.Errorf("ENCODING error: %w", codecErr)
+	}
+	if conf.Transport == TransportWebSocket && codec != domain.JSONCodec {
+		return nil, errors.New("ENCODING other than json is not supported with the ws transport")
+	}
+	conf.codec = codec
+	if ec.Compress != "" {
+		conf.Compress = ec.Compress
+	} else {
+		conf.Compress = fc.Compress
+	}
+	if conf.Compress == compression.Identity {
+		conf.Compress = ""
+	}
+	if conf.Compress != "" && !compression.IsSupported(conf.Compress) {
+		return nil, fmt.Errorf("unknown COMPRESS: %s", conf.Compress)
+	}
+	if conf.Compress != "" && conf.Transport == TransportWebSocket {
+		return nil, errors.New("COMPRESS is not supported with the ws transport")
+	}
+	if ec.HashKeys != "" {
+		conf.HashKeys = ec.HashKeys
+	} else {
+		conf.HashKeys = fc.HashKeys
+	}
+	keySet, keySetErr := buildKeySet(conf.HashKeys, conf.HashKey, conf.HashKeyID)
+	if keySetErr != nil {
+		return nil, keySetErr
+	}
+	conf.keySet = keySet
+	if conf.TLS || conf.TLSCA != "" || conf.TLSCert != "" {
+		tlsConfig, tlsErr := buildAgentTLSConfig(conf)
+		if tlsErr != nil {
+			return nil, tlsErr
+		}
+
+		conf.TLS = true
+		conf.tlsConfig = tlsConfig
+	}
+	if conf.CryptoKey != "" {
+		if conf.Transport == TransportWebSocket {
+			return nil, errors.New("CRYPTO_KEY is not supported with the ws transport")
+		}
+
+		publicKey, keyErr := encryption.LoadPublicKey(conf.CryptoKey)
+		if keyErr != nil {
+			return nil, fmt.Errorf("load CRYPTO_KEY error: %w", keyErr)
+		}
+
+		conf.publicKey = publicKey
+	}
 
 	logger := slog.Default()
 	logger.Info("final agent params",
@@ -179,11 +606,36 @@ func NewAgentConfig(fc *FlagContainer, ec *EnvContainer) (*AgentConfig, error) {
 		slog.Int("RATE_LIMIT", conf.RateLimit),
 		slog.String("LOG_LEVEL", conf.LogLevel),
 		slog.String("KEY", conf.HashKey),
+		slog.String("TRANSPORT", conf.Transport),
+		slog.String("TENANT", conf.Tenant),
+		slog.String("KEY_ID", conf.HashKeyID),
+		slog.String("CRYPTO_KEY", conf.CryptoKey),
+		slog.Bool("TLS", conf.TLS),
+		slog.String("TLS_CA", conf.TLSCA),
+		slog.String("TLS_CERT", conf.TLSCert),
+		slog.Bool("SEND_META", conf.SendMeta),
+		slog.String("ENCODING", conf.Encoding),
+		slog.String("COMPRESS", conf.Compress),
+		slog.Any("KEYS", conf.keySet.IDs()),
 	)
 
 	return conf, nil
 }
 
+func buildAgentTLSConfig(conf *AgentConfig) (*tls.Config, error) {
+	minVersion, versionErr := tlsconfig.ParseVersion(conf.TLSMinVersion)
+	if versionErr != nil {
+		return nil, fmt.Errorf("TLS_MIN_VERSION error: %w", versionErr)
+	}
+
+	tlsConfig, tlsErr := tlsconfig.NewClient(conf.TLSCA, conf.TLSCert, conf.TLSKey, minVersion)
+	if tlsErr != nil {
+		return nil, fmt.Errorf("build tls config error: %w", tlsErr)
+	}
+
+	return tlsConfig, nil
+}
+
 func NewServerConfig(fc *FlagContainer, ec *EnvContainer) (*ServerConfig, error) {
 	conf := &ServerConfig{BaseConfig: buildBaseConfig(AppTypeServer, fc, ec)}
 
@@ -198,6 +650,200 @@ func NewServerConfig(fc *FlagContainer, ec *EnvContainer) (*ServerConfig, error)
 		conf.DSN = fc.DSN
 	}
 
+	if ec.RateWindow != 0 {
+		conf.RateWindow = ec.RateWindow
+	} else {
+		conf.RateWindow = fc.RateWindow
+	}
+	if conf.RateWindow <= 0 {
+		return nil, fmt.Errorf("RATE_WINDOW must be positive, got %d", conf.RateWindow)
+	}
+	if ec.AlertRulesPath != "" {
+		conf.AlertRulesPath = ec.AlertRulesPath
+	} else {
+		conf.AlertRulesPath = fc.AlertRulesPath
+	}
+	if ec.AlertInterval != 0 {
+		conf.AlertInterval = ec.AlertInterval
+	} else {
+		conf.AlertInterval = fc.AlertInterval
+	}
+	if conf.AlertInterval <= 0 {
+		return nil, fmt.Errorf("ALERT_INTERVAL must be positive, got %d", conf.AlertInterval)
+	}
+	if ec.AlertWebhooks != "" {
+		conf.AlertWebhooks = splitList(ec.AlertWebhooks)
+	} else {
+		conf.AlertWebhooks = splitList(fc.AlertWebhooks)
+	}
+	for _, webhook := range conf.AlertWebhooks {
+		if _, webhookErr := domain.ParseAlertWebhook(webhook); webhookErr != nil {
+			return nil, fmt.Errorf("ALERT_WEBHOOKS error: %w", webhookErr)
+		}
+	}
+	if ec.AlertWebhookKey != "" {
+		conf.AlertWebhookKey = ec.AlertWebhookKey
+	} else {
+		conf.AlertWebhookKey = fc.AlertWebhookKey
+	}
+	conf.AuthEnabled = ec.AuthEnabled || fc.AuthEnabled
+	conf.HashStrict = ec.HashStrict || fc.HashStrict
+	if ec.APIKeysFile != "" {
+		conf.APIKeysFile = ec.APIKeysFile
+	} else {
+		conf.APIKeysFile = fc.APIKeysFile
+	}
+	if ec.AdminAPIKey != "" {
+		conf.AdminAPIKey = ec.AdminAPIKey
+	} else {
+		conf.AdminAPIKey = fc.AdminAPIKey
+	}
+	if ec.HashKeys != "" {
+		conf.HashKeys = ec.HashKeys
+	} else {
+		conf.HashKeys = fc.HashKeys
+	}
+
+	if ec.ReplayWindow != 0 {
+		conf.ReplayWindow = ec.ReplayWindow
+	} else {
+		conf.ReplayWindow = fc.ReplayWindow
+	}
+	if conf.ReplayWindow <= 0 {
+		return nil, fmt.Errorf("REPLAY_WINDOW must be positive, got %d", conf.ReplayWindow)
+	}
+
+	keySet, keySetErr := buildKeySet(conf.HashKeys, conf.HashKey, conf.HashKeyID)
+	if keySetErr != nil {
+		return nil, keySetErr
+	}
+
+	conf.keySet = keySet
+
+	if conf.CryptoKey != "" {
+		privateKey, keyErr := encryption.LoadPrivateKey(conf.CryptoKey)
+		if keyErr != nil {
+			return nil, fmt.Errorf("load CRYPTO_KEY error: %w", keyErr)
+		}
+
+		conf.privateKey = privateKey
+	}
+
+	if ec.TLSClientCA != "" {
+		conf.TLSClientCA = ec.TLSClientCA
+	} else {
+		conf.TLSClientCA = fc.TLSClientCA
+	}
+
+	tlsConfig, tlsErr := buildServerTLSConfig(conf)
+	if tlsErr != nil {
+		return nil, tlsErr
+	}
+
+	conf.tlsConfig = tlsConfig
+
+	trustedSubnet := fc.TrustedSubnet
+	if ec.TrustedSubnet != "" {
+		trustedSubnet = ec.TrustedSubnet
+	}
+	trustedSubnets, subnetErr := parseSubnets(trustedSubnet)
+	if subnetErr != nil {
+		return nil, fmt.Errorf("TRUSTED_SUBNET error: %w", subnetErr)
+	}
+	conf.TrustedSubnets = trustedSubnets
+
+	trustedReadSubnet := fc.TrustedReadSubnet
+	if ec.TrustedReadSubnet != "" {
+		trustedReadSubnet = ec.TrustedReadSubnet
+	}
+	trustedReadSubnets, readSubnetErr := parseSubnets(trustedReadSubnet)
+	if readSubnetErr != nil {
+		return nil, fmt.Errorf("TRUSTED_READ_SUBNET error: %w", readSubnetErr)
+	}
+	conf.TrustedReadSubnets = trustedReadSubnets
+
+	trustedProxy := fc.TrustedProxy
+	if ec.TrustedProxy != "" {
+		trustedProxy = ec.TrustedProxy
+	}
+	trustedProxies, proxyErr := parseSubnets(trustedProxy)
+	if proxyErr != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXY error: %w", proxyErr)
+	}
+	conf.TrustedProxies = trustedProxies
+
+	if ec.LimitUpdateRPS != 0 {
+		conf.LimitUpdateRPS = ec.LimitUpdateRPS
+	} else {
+		conf.LimitUpdateRPS = fc.LimitUpdateRPS
+	}
+	if ec.LimitBatchRPS != 0 {
+		conf.LimitBatchRPS = ec.LimitBatchRPS
+	} else {
+		conf.LimitBatchRPS = fc.LimitBatchRPS
+	}
+	if ec.LimitGlobalRPS != 0 {
+		conf.LimitGlobalRPS = ec.LimitGlobalRPS
+	} else {
+		conf.LimitGlobalRPS = fc.LimitGlobalRPS
+	}
+	if ec.LimitBurst != 0 {
+		conf.LimitBurst = ec.LimitBurst
+	} else {
+		conf.LimitBurst = fc.LimitBurst
+	}
+	if conf.LimitUpdateRPS < 0 || conf.LimitBatchRPS < 0 || conf.LimitGlobalRPS < 0 || conf.LimitBurst < 0 {
+		return nil, errors.New("rate limits must not be negative")
+	}
+	if ec.MaxBodyBytes != 0 {
+		conf.MaxBodyBytes = ec.MaxBodyBytes
+	} else {
+		conf.MaxBodyBytes = fc.MaxBodyBytes
+	}
+	if ec.MaxDecompressedBytes != 0 {
+		conf.MaxDecompressedBytes = ec.MaxDecompressedBytes
+	} else {
+		conf.MaxDecompressedBytes = fc.MaxDecompressedBytes
+	}
+	if conf.MaxBodyBytes <= 0 || conf.MaxDecompressedBytes <= 0 {
+		return nil, errors.New("MAX_BODY_BYTES and MAX_DECOMPRESSED_BYTES must be positive")
+	}
+	if ec.MetricNamePattern != "" {
+		conf.MetricNamePattern = ec.MetricNamePattern
+	} else {
+		conf.MetricNamePattern = fc.MetricNamePattern
+	}
+	if ec.MetricNameMaxLen != 0 {
+		conf.MetricNameMaxLen = ec.MetricNameMaxLen
+	} else {
+		conf.MetricNameMaxLen = fc.MetricNameMaxLen
+	}
+	if ec.MaxSeries != 0 {
+		conf.MaxSeries = ec.MaxSeries
+	} else {
+		conf.MaxSeries = fc.MaxSeries
+	}
+	if ec.MaxSeriesPerAgent != 0 {
+		conf.MaxSeriesPerAgent = ec.MaxSeriesPerAgent
+	} else {
+		conf.MaxSeriesPerAgent = fc.MaxSeriesPerAgent
+	}
+	if ec.MaxTenants != 0 {
+		conf.MaxTenants = ec.MaxTenants
+	} else {
+		conf.MaxTenants = fc.MaxTenants
+	}
+	if conf.MaxSeries < 0 || conf.MaxSeriesPerAgent < 0 || conf.MaxTenants < 0 {
+		return nil, errors.New("MAX_SERIES, MAX_SERIES_PER_AGENT and MAX_TENANTS must not be negative")
+	}
+
+	namePolicy, policyErr := domain.NewNamePolicy(conf.MetricNamePattern, conf.MetricNameMaxLen)
+	if policyErr != nil {
+		return nil, fmt.Errorf("METRIC_NAME_PATTERN error: %w", policyErr)
+	}
+
+	conf.namePolicy = namePolicy
+
 	v, ok := os.LookupEnv("STORE_INTERVAL")
 	if ok {
 		vInt, vErr := strconv.Atoi(v)
@@ -231,11 +877,95 @@ func NewServerConfig(fc *FlagContainer, ec *EnvContainer) (*ServerConfig, error)
 		slog.String("LOG_LEVEL", conf.LogLevel),
 		slog.String("KEY", conf.HashKey),
 		slog.String("DATABASE_DSN", conf.DSN),
+		slog.Int("RATE_WINDOW", conf.RateWindow),
+		slog.String("ALERT_RULES", conf.AlertRulesPath),
+		slog.Int("ALERT_INTERVAL", conf.AlertInterval),
+		slog.Any("ALERT_WEBHOOKS", redactWebhooks(conf.AlertWebhooks)),
+		slog.Bool("ALERT_WEBHOOK_KEY", conf.AlertWebhookKey != ""),
+		slog.Bool("AUTH_ENABLED", conf.AuthEnabled),
+		slog.Bool("HASH_STRICT", conf.HashStrict),
+		slog.String("API_KEYS_FILE", conf.APIKeysFile),
+		slog.String("KEY_ID", conf.HashKeyID),
+		slog.Any("KEYS", conf.keySet.IDs()),
+		slog.Int("REPLAY_WINDOW", conf.ReplayWindow),
+		slog.String("CRYPTO_KEY", conf.CryptoKey),
+		slog.String("TLS_CERT", conf.TLSCert),
+		slog.String("TLS_MIN_VERSION", conf.TLSMinVersion),
+		slog.String("TLS_CLIENT_CA", conf.TLSClientCA),
+		slog.Any("TRUSTED_SUBNET", conf.TrustedSubnets),
+		slog.Any("TRUSTED_READ_SUBNET", conf.TrustedReadSubnets),
+		slog.Any("TRUSTED_PROXY", conf.TrustedProxies),
+		slog.Int("LIMIT_UPDATE_RPS", conf.LimitUpdateRPS),
+		slog.Int("LIMIT_BATCH_RPS", conf.LimitBatchRPS),
+		slog.Int("LIMIT_GLOBAL_RPS", conf.LimitGlobalRPS),
+		slog.Int("LIMIT_BURST", conf.LimitBurst),
+		slog.Int("MAX_BODY_BYTES", conf.MaxBodyBytes),
+		slog.Int("MAX_DECOMPRESSED_BYTES", conf.MaxDecompressedBytes),
+		slog.String("METRIC_NAME_PATTERN", conf.MetricNamePattern),
+		slog.Int("METRIC_NAME_MAX_LEN", conf.MetricNameMaxLen),
+		slog.Int("MAX_SERIES", conf.MaxSeries),
+		slog.Int("MAX_SERIES_PER_AGENT", conf.MaxSeriesPerAgent),
+		slog.Int("MAX_TENANTS", conf.MaxTenants),
 	)
 
 	return conf, nil
 }
 
+// buildServerTLSConfig returns nil when no certificate is configured and the server listens on plain HTTP.
+func buildServerTLSConfig(conf *ServerConfig) (*tls.Config, error) {
+	if conf.TLSCert == "" && conf.TLSKey == "" {
+		if conf.TLSClientCA != "" {
+			return nil, errors.New("TLS_CLIENT_CA requires TLS_CERT and TLS_KEY")
+		}
+
+		return nil, nil
+	}
+
+	if conf.TLSCert == "" || conf.TLSKey == "" {
+		return nil, errors.New("both TLS_CERT and TLS_KEY must be set")
+	}
+
+	minVersion, versionErr := tlsconfig.ParseVersion(conf.TLSMinVersion)
+	if versionErr != nil {
+		return nil, fmt.Errorf("TLS_MIN_VERSION error: %w", versionErr)
+	}
+
+	tlsConfig, tlsErr := tlsconfig.NewServer(
+		conf.TLSCert,
+		conf.TLSKey,
+		minVersion,
+		conf.TLSClientCA,
+	)
+	if tlsErr != nil {
+		return nil, fmt.Errorf("build tls config error: %w", tlsErr)
+	}
+
+	return tlsConfig, nil
+}
+
+// buildKeySet merges the KEYS list with the single KEY, which is registered under KEY_ID.
+func buildKeySet(hashKeys string, hashKey string, hashKeyID string) (*hashing.KeySet, error) {
+	keys, parseErr := hashing.ParseKeys(hashKeys)
+	if parseErr != nil {
+		return nil, fmt.Errorf("parse KEYS error: %w", parseErr)
+	}
+
+	if hashKey != "" {
+		if key, exists := keys[hashKeyID]; exists && key != hashKey {
+			return nil, fmt.Errorf("KEY conflicts with KEYS entry %q", hashKeyID)
+		}
+
+		keys[hashKeyID] = hashKey
+	}
+
+	keySet, keySetErr := hashing.NewKeySet(hashKeyID, keys)
+	if keySetErr != nil {
+		return nil, fmt.Errorf("build key set error: %w", keySetErr)
+	}
+
+	return keySet, nil
+}
+
 func (c *AgentConfig) GetLogLevel() slog.Level {
 	return parseLogLevel(c.LogLevel)
 }
@@ -252,6 +982,38 @@ func (c *AgentConfig) GetHashKey() string {
 	return c.HashKey
 }
 
+func (c *AgentConfig) GetHashKeyID() string {
+	return c.HashKeyID
+}
+
+// GetTLSConfig returns the client tls config, nil when the agent uses plain HTTP.
+func (c *AgentConfig) GetTLSConfig() *tls.Config {
+	return c.tlsConfig
+}
+
+// GetHTTPScheme returns the scheme of the server URLs: http or https.
+func (c *AgentConfig) GetHTTPScheme() string {
+	if c.tlsConfig != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// GetWSScheme returns the scheme of the websocket URL: ws or wss.
+func (c *AgentConfig) GetWSScheme() string {
+	if c.tlsConfig != nil {
+		return "wss"
+	}
+
+	return "ws"
+}
+
+// GetPublicKey returns the server key payloads are encrypted for, nil when encryption is off.
+func (c *AgentConfig) GetPublicKey() *rsa.PublicKey {
+	return c.publicKey
+}
+
 func (c *ServerConfig) GetAddress() string {
 	return c.Address
 }
@@ -260,6 +1022,40 @@ func (c *AgentConfig) GetReportIntervalDuration() time.Duration {
 	return time.Duration(c.ReportInterval) * time.Second
 }
 
+func (c *AgentConfig) GetTenant() string {
+	return c.Tenant
+}
+
+func (c *AgentConfig) GetAPIKey() string {
+	return c.APIKey
+}
+
+// GetKeySet returns the keys response signatures are verified with, it is empty when signing is disabled.
+func (c *AgentConfig) GetKeySet() *hashing.KeySet {
+	return c.keySet
+}
+
+// GetCodec returns the codec the metric forms are encoded with.
+// The agent reports every metric to its /update/<type>/<name>/<value> path, so for the agent it only
+// encodes the body carrying the metadata; /updates/ batches in it are sent by collectorctl and loadgen -batch.
+func (c *AgentConfig) GetCodec() domain.Codec {
+	return c.codec
+}
+
+// GetCompress returns the encoding request bodies are compressed with, empty when they aren't.
+func (c *AgentConfig) GetCompress() string {
+	return c.Compress
+}
+
+// IsSendMeta reports whether the agent annotates the metrics with units and help.
+func (c *AgentConfig) IsSendMeta() bool {
+	return c.SendMeta
+}
+
+func (c *AgentConfig) IsWebSocketTransport() bool {
+	return c.Transport == TransportWebSocket
+}
+
 func (c *AgentConfig) GetPollIntervalDuration() time.Duration {
 	return time.Duration(c.PollInterval) * time.Second
 }
@@ -268,6 +1064,111 @@ func (c *ServerConfig) GetStoreIntervalDuration() time.Duration {
 	return time.Duration(c.StoreInterval) * time.Second
 }
 
+func (c *ServerConfig) GetRateWindowDuration() time.Duration {
+	return time.Duration(c.RateWindow) * time.Second
+}
+
+func (c *ServerConfig) GetAlertRulesPath() string {
+	return c.AlertRulesPath
+}
+
+func (c *ServerConfig) GetAlertIntervalDuration() time.Duration {
+	return time.Duration(c.AlertInterval) * time.Second
+}
+
+func (c *ServerConfig) GetAlertWebhooks() []string {
+	return c.AlertWebhooks
+}
+
+func (c *ServerConfig) GetAlertWebhookKey() string {
+	return c.AlertWebhookKey
+}
+
+func (c *ServerConfig) IsAuthEnabled() bool {
+	return c.AuthEnabled
+}
+
+func (c *ServerConfig) IsHashStrict() bool {
+	return c.HashStrict
+}
+
+// GetTLSConfig returns the listener tls config, nil when the server uses plain HTTP.
+func (c *ServerConfig) GetTLSConfig() *tls.Config {
+	return c.tlsConfig
+}
+
+// GetTrustedSubnets returns the networks allowed to write, empty means no restriction.
+func (c *ServerConfig) GetTrustedSubnets() []netip.Prefix {
+	return c.TrustedSubnets
+}
+
+// GetTrustedProxies returns the networks of the proxies allowed to set X-Real-IP.
+func (c *ServerConfig) GetTrustedProxies() []netip.Prefix {
+	return c.TrustedProxies
+}
+
+// GetTrustedReadSubnets returns the networks allowed to read, empty means no restriction.
+func (c *ServerConfig) GetTrustedReadSubnets() []netip.Prefix {
+	return c.TrustedReadSubnets
+}
+
+func (c *ServerConfig) GetLimitUpdateRPS() int {
+	return c.LimitUpdateRPS
+}
+
+func (c *ServerConfig) GetLimitBatchRPS() int {
+	return c.LimitBatchRPS
+}
+
+func (c *ServerConfig) GetLimitGlobalRPS() int {
+	return c.LimitGlobalRPS
+}
+
+func (c *ServerConfig) GetLimitBurst() int {
+	return c.LimitBurst
+}
+
+func (c *ServerConfig) GetMaxBodyBytes() int64 {
+	return int64(c.MaxBodyBytes)
+}
+
+func (c *ServerConfig) GetMaxDecompressedBytes() int64 {
+	return int64(c.MaxDecompressedBytes)
+}
+
+func (c *ServerConfig) GetNamePolicy() *domain.NamePolicy {
+	return c.namePolicy
+}
+
+func (c *ServerConfig) GetMaxSeries() int {
+	return c.MaxSeries
+}
+
+func (c *ServerConfig) GetMaxSeriesPerAgent() int {
+	return c.MaxSeriesPerAgent
+}
+
+func (c *ServerConfig) GetMaxTenants() int {
+	return c.MaxTenants
+}
+
+// GetPrivateKey returns the key encrypted payloads are decrypted with, nil when encryption is off.
+func (c *ServerConfig) GetPrivateKey() *rsa.PrivateKey {
+	return c.privateKey
+}
+
+func (c *ServerConfig) GetReplayWindowDuration() time.Duration {
+	return time.Duration(c.ReplayWindow) * time.Second
+}
+
+func (c *ServerConfig) GetAPIKeysFile() string {
+	return c.APIKeysFile
+}
+
+func (c *ServerConfig) GetAdminAPIKey() string {
+	return c.AdminAPIKey
+}
+
 func (c *ServerConfig) GetStoreInterval() int {
 	return c.StoreInterval
 }
@@ -276,8 +1177,54 @@ func (c *ServerConfig) GetDSN() string {
 	return c.DSN
 }
 
-func (c *ServerConfig) GetHashKey() string {
-	return c.HashKey
+// GetKeySet returns the active hash keys, it is empty when signing is disabled.
+func (c *ServerConfig) GetKeySet() *hashing.KeySet {
+	return c.keySet
+}
+
+func parseSubnets(list string) ([]netip.Prefix, error) {
+	var subnets []netip.Prefix
+
+	for _, item := range splitList(list) {
+		subnet, parseErr := netip.ParsePrefix(item)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse subnet error: %w", parseErr)
+		}
+
+		subnets = append(subnets, subnet.Masked())
+	}
+
+	return subnets, nil
+}
+
+// redactWebhooks cuts the webhooks to their scheme and host for the logs, the rest of a URL may be a secret.
+func redactWebhooks(entries []string) []string {
+	redacted := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		webhook, parseErr := domain.ParseAlertWebhook(entry)
+		if parseErr != nil {
+			redacted = append(redacted, "<invalid url>")
+
+			continue
+		}
+
+		redacted = append(redacted, webhook.Redacted())
+	}
+
+	return redacted
+}
+
+func splitList(list string) []string {
+	var items []string
+
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
 }
 
 func parseLogLevel(lvl string) slog.Level {
@@ -0,0 +1,32 @@
+package domain
+
+import "context"
+
+type agentKey string
+
+const agentCtxKey = agentKey("agent")
+
+// WithAgentID binds the agent identity established by the client certificate.
+func WithAgentID(ctx context.Context, agentID string) context.Context {
+	return context.WithValue(ctx, agentCtxKey, agentID)
+}
+
+// AgentIDFromContext returns the identity of the calling agent if it presented a client certificate.
+func AgentIDFromContext(ctx context.Context) (string, bool) {
+	agentID, ok := ctx.Value(agentCtxKey).(string)
+
+	return agentID, ok && agentID != ""
+}
+
+const clientCtxKey = agentKey("client")
+
+// WithClientID binds the key the caller is accounted under for rate and series limits.
+func WithClientID(ctx context.Context, clientID string) context.Context {
+	return context.WithValue(ctx, clientCtxKey, clientID)
+}
+
+func ClientIDFromContext(ctx context.Context) string {
+	clientID, _ := ctx.Value(clientCtxKey).(string)
+
+	return clientID
+}
@@ -0,0 +1,48 @@
+package domain
+
+type Aggregation struct {
+	Count int      `json:"count"`
+	Sum   float64  `json:"sum"`
+	Avg   *float64 `json:"avg,omitempty"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+// Aggregate folds gauge values and counter totals of the forms into a single summary,
+// the forms are expected to be of one type.
+func Aggregate(forms []MetricForm) *Aggregation {
+	agg := &Aggregation{}
+
+	var minVal, maxVal float64
+
+	for _, form := range forms {
+		var val float64
+
+		switch {
+		case form.IsGaugeType() && form.Value != nil:
+			val = *form.Value
+		case form.IsCounterType() && form.Delta != nil:
+			val = float64(*form.Delta)
+		default:
+			continue
+		}
+
+		if agg.Count == 0 || val < minVal {
+			minVal = val
+		}
+
+		if agg.Count == 0 || val > maxVal {
+			maxVal = val
+		}
+
+		agg.Count++
+		agg.Sum += val
+	}
+
+	if agg.Count > 0 {
+		avg := agg.Sum / float64(agg.Count)
+		agg.Avg, agg.Min, agg.Max = &avg, &minVal, &maxVal
+	}
+
+	return agg
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "testing"
+
+func TestAggregate(t *testing.T) {
+	gauge := func(value float64) MetricForm { return MetricForm{MType: MetricTypeGauge, Value: &value} }
+	counter := func(delta int64) MetricForm { return MetricForm{MType: MetricTypeCounter, Delta: &delta} }
+
+	tests := []struct {
+		name      string
+		forms     []MetricForm
+		wantCount int
+		wantSum   float64
+		wantMin   float64
+		wantMax   float64
+	}{
+		{name: "no forms"},
+		{
+			name:      "gauges",
+			forms:     []MetricForm{gauge(2), gauge(-1), gauge(5)},
+			wantCount: 3,
+			wantSum:   6,
+			wantMin:   -1,
+			wantMax:   5,
+		},
+		{
+			name:      "counters",
+			forms:     []MetricForm{counter(10), counter(30)},
+			wantCount: 2,
+			wantSum:   40,
+			wantMin:   10,
+			wantMax:   30,
+		},
+		{
+			name:      "forms without values are skipped",
+			forms:     []MetricForm{gauge(4), {MType: MetricTypeGauge}},
+			wantCount: 1,
+			wantSum:   4,
+			wantMin:   4,
+			wantMax:   4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Aggregate(tt.forms)
+
+			if got.Count != tt.wantCount || got.Sum != tt.wantSum {
+				t.Fatalf("Aggregate() count, sum = %d, %v, want %d, %v", got.Count, got.Sum, tt.wantCount, tt.wantSum)
+			}
+
+			if tt.wantCount == 0 {
+				if got.Avg != nil || got.Min != nil || got.Max != nil {
+					t.Errorf("Aggregate() of nothing = %+v, want no avg, min and max", got)
+				}
+
+				return
+			}
+
+			if *got.Min != tt.wantMin || *got.Max != tt.wantMax || *got.Avg != tt.wantSum/float64(tt.wantCount) {
+				t.Errorf("Aggregate() avg, min, max = %v, %v, %v", *got.Avg, *got.Min, *got.Max)
+			}
+		})
+	}
+}
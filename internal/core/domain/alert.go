@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+type AlertState string
+
+const (
+	AlertStatePending  = AlertState("pending")
+	AlertStateFiring   = AlertState("firing")
+	AlertStateResolved = AlertState("resolved")
+
+	AlertSourceValue = "value"
+	AlertSourceRate  = "rate"
+)
+
+var (
+	errEmptyRuleName    = errors.New("rule name is empty")
+	errEmptyRuleMatch   = errors.New("rule match is empty")
+	errUnknownRuleOp    = errors.New("unknown rule comparison")
+	errUnknownRuleSrc   = errors.New("unknown rule source")
+	errRateOnGaugeRule  = errors.New("rate source applies to counters only")
+	errNegativeDuration = errors.New("rule for duration is negative")
+	errInvalidWebhook   = errors.New("webhook must be an http or https url")
+)
+
+// Duration is a time.Duration read from and written to JSON as a string like "5m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var raw string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("parse duration error: %w", err)
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// AlertRule raises a separate alert for every metric matching the pattern
+// whose value keeps breaching the threshold for at least the For duration.
+// A rule with a tenant only applies to the metrics of that tenant, otherwise to every tenant.
+type AlertRule struct {
+	Name      string     `json:"name"`
+	Tenant    string     `json:"tenant,omitempty"`
+	Match     string     `json:"match"`
+	Type      MetricType `json:"type,omitempty"`
+	Source    string     `json:"source,omitempty"`
+	Op        string     `json:"op"`
+	Threshold float64    `json:"threshold"`
+	For       Duration   `json:"for"`
+}
+
+type Alert struct {
+	Tenant     string     `json:"tenant"`
+	Rule       string     `json:"rule"`
+	Metric     string     `json:"metric"`
+	Type       MetricType `json:"type"`
+	State      AlertState `json:"state"`
+	Value      float64    `json:"value"`
+	Op         string     `json:"op"`
+	Threshold  float64    `json:"threshold"`
+	ActiveAt   time.Time  `json:"active_at"`
+	FiredAt    *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+func (r *AlertRule) Validate() error {
+	switch {
+	case r.Name == "":
+		return errEmptyRuleName
+	case r.Match == "":
+		return errEmptyRuleMatch
+	case r.Tenant != "" && ValidateTenantID(r.Tenant) != nil:
+		return fmt.Errorf("rule %s: %w", r.Name, ErrInvalidTenantID)
+	case r.Type != "" && !r.Type.IsValid():
+		return fmt.Errorf("rule %s: %w", r.Name, errUnknownMetricType)
+	case r.Source != "" && r.Source != AlertSourceValue && r.Source != AlertSourceRate:
+		return fmt.Errorf("rule %s: %w", r.Name, errUnknownRuleSrc)
+	case r.Source == AlertSourceRate && r.Type == MetricTypeGauge:
+		return fmt.Errorf("rule %s: %w", r.Name, errRateOnGaugeRule)
+	case r.For < 0:
+		return fmt.Errorf("rule %s: %w", r.Name, errNegativeDuration)
+	}
+
+	if _, matchErr := path.Match(r.Match, ""); matchErr != nil {
+		return fmt.Errorf("rule %s: invalid match pattern: %w", r.Name, matchErr)
+	}
+
+	if _, opErr := r.Compare(0); opErr != nil {
+		return fmt.Errorf("rule %s: %w", r.Name, opErr)
+	}
+
+	return nil
+}
+
+func (r *AlertRule) IsRateSource() bool {
+	return r.Source == AlertSourceRate
+}
+
+// Compare reports whether the value breaches the rule threshold.
+func (r *AlertRule) Compare(value float64) (bool, error) {
+	switch r.Op {
+	case ">":
+		return value > r.Threshold, nil
+	case ">=":
+		return value >= r.Threshold, nil
+	case "<":
+		return value < r.Threshold, nil
+	case "<=":
+		return value <= r.Threshold, nil
+	case "==":
+		return value == r.Threshold, nil
+	case "!=":
+		return value != r.Threshold, nil
+	default:
+		return false, errUnknownRuleOp
+	}
+}
+
+// AlertWebhook receives the alerts of a single tenant.
+type AlertWebhook struct {
+	Tenant string
+	URL    string
+}
+
+// ParseAlertWebhook reads a webhook given as <tenant>=<url>, a bare URL receives the alerts of the default tenant.
+func ParseAlertWebhook(entry string) (AlertWebhook, error) {
+	webhook := AlertWebhook{Tenant: DefaultTenant, URL: entry}
+
+	if tenant, rawURL, found := strings.Cut(entry, "="); found && ValidateTenantID(tenant) == nil {
+		webhook = AlertWebhook{Tenant: tenant, URL: rawURL}
+	}
+
+	parsed, parseErr := url.Parse(webhook.URL)
+	if parseErr != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return AlertWebhook{}, errInvalidWebhook
+	}
+
+	return webhook, nil
+}
+
+// Redacted returns the webhook with the URL cut to its scheme and host,
+// chat and paging services carry the webhook secret in the path or query.
+func (w AlertWebhook) Redacted() string {
+	parsed, parseErr := url.Parse(w.URL)
+	if parseErr != nil {
+		return w.Tenant + "=<invalid url>"
+	}
+
+	return w.Tenant + "=" + parsed.Scheme + "://" + parsed.Host
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAlertWebhook(t *testing.T) {
+	tests := []struct {
+		name         string
+		entry        string
+		wantTenant   string
+		wantRedacted string
+		wantErr      error
+	}{
+		{
+			name:         "default tenant",
+			entry:        "https://hooks.example.com/services/T0/B0/secret?token=abc",
+			wantTenant:   DefaultTenant,
+			wantRedacted: "default=https://hooks.example.com",
+		},
+		{
+			name:         "tenant webhook",
+			entry:        "team-a=https://hooks.example.com/secret",
+			wantTenant:   "team-a",
+			wantRedacted: "team-a=https://hooks.example.com",
+		},
+		{
+			name:         "equal sign in the query",
+			entry:        "https://hooks.example.com/send?key=secret",
+			wantTenant:   DefaultTenant,
+			wantRedacted: "default=https://hooks.example.com",
+		},
+		{name: "not an http url", entry: "team-a=ftp://example.com", wantErr: errInvalidWebhook},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAlertWebhook(tt.entry)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseAlertWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got.Tenant != tt.wantTenant || got.Redacted() != tt.wantRedacted {
+				t.Errorf(
+					"ParseAlertWebhook() = %s, %s, want %s, %s",
+					got.Tenant,
+					got.Redacted(),
+					tt.wantTenant,
+					tt.wantRedacted,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type Scope string
+
+const (
+	ScopeRead  = Scope("read")
+	ScopeWrite = Scope("write")
+	ScopeAdmin = Scope("admin")
+
+	APIKeyHeader = "X-API-Key"
+
+	apiKeyPrefix = "ck_"
+)
+
+type apiKeyCtxKey string
+
+const apiKeyKey = apiKeyCtxKey("api_key")
+
+var (
+	ErrUnknownScope   = errors.New("unknown scope")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrForeignTenant  = errors.New("api key is bound to another tenant")
+)
+
+// APIKey grants its scopes to the requests presenting the secret it was generated with.
+// Only the SHA-256 hash of the secret is kept. A key bound to a tenant can't act on other tenants.
+type APIKey struct {
+	ID        string    `json:"id"`
+	Hash      string    `json:"hash,omitempty"`
+	Scopes    []Scope   `json:"scopes"`
+	Tenant    string    `json:"tenant,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s Scope) IsValid() bool {
+	return s == ScopeRead || s == ScopeWrite || s == ScopeAdmin
+}
+
+// HasScope reports whether the key grants the scope. Admin keys are granted every scope.
+func (k *APIKey) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, ScopeAdmin) || slices.Contains(k.Scopes, scope)
+}
+
+func (k *APIKey) Validate() error {
+	if len(k.Scopes) == 0 {
+		return fmt.Errorf("%w: no scopes", ErrUnknownScope)
+	}
+
+	for _, scope := range k.Scopes {
+		if !scope.IsValid() {
+			return fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+
+	if k.Tenant != "" {
+		return ValidateTenantID(k.Tenant)
+	}
+
+	return nil
+}
+
+// NewAPIKey generates a key with a random secret, returning the secret that is never stored.
+func NewAPIKey(scopes []Scope, tenant string) (*APIKey, string, error) {
+	idBytes := make([]byte, 8)
+	secretBytes := make([]byte, 32)
+
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, "", fmt.Errorf("generate api key id error: %w", err)
+	}
+
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, "", fmt.Errorf("generate api key secret error: %w", err)
+	}
+
+	secret := apiKeyPrefix + hex.EncodeToString(secretBytes)
+	key := &APIKey{
+		ID:        hex.EncodeToString(idBytes),
+		Hash:      HashAPIKey(secret),
+		Scopes:    scopes,
+		Tenant:    tenant,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := key.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	return key, secret, nil
+}
+
+func HashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func WithAPIKey(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey, key)
+}
+
+func APIKeyFromContext(ctx context.Context) (*APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey).(*APIKey)
+
+	return key, ok
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestAPIKey_HasScope(t *testing.T) {
+	type args struct {
+		scope Scope
+	}
+	tests := []struct {
+		name   string
+		scopes []Scope
+		args   args
+		want   bool
+	}{
+		{
+			name:   "granted scope",
+			scopes: []Scope{ScopeRead},
+			args:   args{scope: ScopeRead},
+			want:   true,
+		},
+		{
+			name:   "missing scope",
+			scopes: []Scope{ScopeRead},
+			args:   args{scope: ScopeWrite},
+			want:   false,
+		},
+		{
+			name:   "admin grants everything",
+			scopes: []Scope{ScopeAdmin},
+			args:   args{scope: ScopeWrite},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, secret, err := NewAPIKey(tt.scopes, DefaultTenant)
+			if err != nil {
+				t.Fatalf("NewAPIKey() error = %v", err)
+			}
+
+			if key.Hash != HashAPIKey(secret) {
+				t.Errorf("NewAPIKey() hash doesn't match the secret")
+			}
+
+			if got := key.HasScope(tt.args.scope); got != tt.want {
+				t.Errorf("HasScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	BatchStatusOK    = "ok"
+	BatchStatusError = "error"
+)
+
+// MetricBatch is a message of the websocket ingestion channel.
+// Metrics keeps the raw JSON array so the signature is checked against the exact bytes sent.
+// Signed batches carry a timestamp and nonce, the hash covers SignaturePayload of them and the metrics.
+type MetricBatch struct {
+	ID        uint64          `json:"id"`
+	Metrics   json.RawMessage `json:"metrics"`
+	Hash      string          `json:"hash,omitempty"`
+	KeyID     string          `json:"key_id,omitempty"`
+	Timestamp string          `json:"timestamp,omitempty"`
+	Nonce     string          `json:"nonce,omitempty"`
+}
+
+// BatchAck acknowledges the MetricBatch with the same ID.
+type BatchAck struct {
+	ID     uint64 `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (b *MetricBatch) Forms() ([]MetricForm, error) {
+	var forms []MetricForm
+
+	if err := json.Unmarshal(b.Metrics, &forms); err != nil {
+		return nil, err
+	}
+
+	return forms, nil
+}
+
+// BatchLog remembers the batches applied recently, so a batch sent again after a lost ack isn't applied twice.
+// Entries expire after ttl; once limit entries are live new batches are not remembered until some expire.
+type BatchLog struct {
+	entries   map[string]*batchEntry
+	ttl       time.Duration
+	limit     int
+	lastPrune time.Time
+	mx        *sync.Mutex
+}
+
+// batchEntry is a batch being applied, pending is closed once the apply ends and is nil after a commit.
+type batchEntry struct {
+	expires time.Time
+	pending chan struct{}
+}
+
+func NewBatchLog(ttl time.Duration, limit int) *BatchLog {
+	return &BatchLog{
+		entries: make(map[string]*batchEntry),
+		ttl:     ttl,
+		limit:   limit,
+		mx:      new(sync.Mutex),
+	}
+}
+
+// TryBegin reserves the batch with the key for the caller, which must then Commit or Abort it.
+// It returns false when the batch was applied within the ttl, or false with a channel
+// closed once the apply in flight on another connection ends, when TryBegin should be called again.
+func (l *BatchLog) TryBegin(key string, now time.Time) (bool, <-chan struct{}) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if entry, ok := l.entries[key]; ok {
+		if entry.pending != nil {
+			return false, entry.pending
+		}
+
+		if !now.After(entry.expires) {
+			return false, nil
+		}
+	}
+
+	if now.Sub(l.lastPrune) >= l.ttl || len(l.entries) >= l.limit {
+		l.prune(now)
+	}
+
+	if len(l.entries) < l.limit {
+		l.entries[key] = &batchEntry{pending: make(chan struct{})}
+	}
+
+	return true, nil
+}
+
+// Commit records the reserved batch as applied.
+func (l *BatchLog) Commit(key string, now time.Time) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if entry, ok := l.entries[key]; ok && entry.pending != nil {
+		close(entry.pending)
+		entry.pending = nil
+		entry.expires = now.Add(l.ttl)
+	}
+}
+
+// Abort releases the reserved batch that failed to apply, so it may be applied when sent again.
+func (l *BatchLog) Abort(key string) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if entry, ok := l.entries[key]; ok && entry.pending != nil {
+		close(entry.pending)
+		delete(l.entries, key)
+	}
+}
+
+func (l *BatchLog) prune(now time.Time) {
+	for key, entry := range l.entries {
+		if entry.pending == nil && now.After(entry.expires) {
+			delete(l.entries, key)
+		}
+	}
+
+	l.lastPrune = now
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBatchLog_TryBegin(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	applied := func(keys ...string) func(log *BatchLog) {
+		return func(log *BatchLog) {
+			for _, key := range keys {
+				log.TryBegin(key, now)
+				log.Commit(key, now)
+			}
+		}
+	}
+
+	tests := []struct {
+		name        string
+		setup       func(log *BatchLog)
+		key         string
+		at          time.Time
+		wantBegun   bool
+		wantPending bool
+	}{
+		{name: "new batch", setup: applied(), key: "agent:a1/1", at: now, wantBegun: true},
+		{name: "applied batch", setup: applied("agent:a1/1"), key: "agent:a1/1", at: now},
+		{
+			name:        "batch in flight",
+			setup:       func(log *BatchLog) { log.TryBegin("agent:a1/1", now) },
+			key:         "agent:a1/1",
+			at:          now,
+			wantPending: true,
+		},
+		{
+			name: "aborted batch",
+			setup: func(log *BatchLog) {
+				log.TryBegin("agent:a1/1", now)
+				log.Abort("agent:a1/1")
+			},
+			key:       "agent:a1/1",
+			at:        now,
+			wantBegun: true,
+		},
+		{name: "same id of another client", setup: applied("agent:a1/1"), key: "agent:a2/1", at: now, wantBegun: true},
+		{
+			name:      "expired batch",
+			setup:     applied("agent:a1/1"),
+			key:       "agent:a1/1",
+			at:        now.Add(2 * time.Minute),
+			wantBegun: true,
+		},
+		{
+			name:      "batch over the limit isn't remembered",
+			setup:     applied("agent:a1/1", "agent:a1/2", "agent:a1/3"),
+			key:       "agent:a1/3",
+			at:        now,
+			wantBegun: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := NewBatchLog(time.Minute, 2)
+			tt.setup(log)
+
+			begun, pending := log.TryBegin(tt.key, tt.at)
+			if begun != tt.wantBegun || (pending != nil) != tt.wantPending {
+				t.Errorf(
+					"TryBegin(%q) = %v, pending %v, want %v, pending %v",
+					tt.key,
+					begun,
+					pending != nil,
+					tt.wantBegun,
+					tt.wantPending,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeMsgPack  = "application/msgpack"
+
+	EncodingJSON     = "json"
+	EncodingProtobuf = "protobuf"
+	EncodingMsgPack  = "msgpack"
+)
+
+// Codec encodes metric forms in one of the supported body formats.
+type Codec interface {
+	ContentType() string
+	MarshalForm(form *MetricForm) ([]byte, error)
+	UnmarshalForm(data []byte, form *MetricForm) error
+	MarshalForms(forms []MetricForm) ([]byte, error)
+	UnmarshalForms(data []byte) ([]MetricForm, error)
+}
+
+var (
+	JSONCodec     Codec = jsonCodec{}
+	ProtobufCodec Codec = protobufCodec{}
+	MsgPackCodec  Codec = msgpackCodec{}
+)
+
+// CodecByName returns the codec of the encoding option.
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "", EncodingJSON:
+		return JSONCodec, nil
+	case EncodingProtobuf:
+		return ProtobufCodec, nil
+	case EncodingMsgPack:
+		return MsgPackCodec, nil
+	default:
+		return nil, fmt.Errorf("unknown encoding: %s", name)
+	}
+}
+
+// CodecByContentType returns the codec of the request body.
+// Bodies of unknown content types are treated as JSON.
+func CodecByContentType(contentType string) Codec {
+	if codec, ok := codecByMediaType(contentType); ok {
+		return codec
+	}
+
+	return JSONCodec
+}
+
+// NegotiateCodec picks the most preferred codec listed in the Accept header, fallback when none is.
+func NegotiateCodec(accept string, fallback Codec) Codec {
+	type mediaRange struct {
+		codec Codec
+		q     float64
+	}
+
+	var ranges []mediaRange
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, parseErr := mime.ParseMediaType(strings.TrimSpace(part))
+		if parseErr != nil {
+			continue
+		}
+
+		codec, ok := codecByMediaType(mediaType)
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if rawQ, hasQ := params["q"]; hasQ {
+			if parsedQ, convErr := strconv.ParseFloat(rawQ, 64); convErr == nil {
+				q = parsedQ
+			}
+		}
+
+		if q > 0 {
+			ranges = append(ranges, mediaRange{codec: codec, q: q})
+		}
+	}
+
+	if len(ranges) == 0 {
+		return fallback
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	return ranges[0].codec
+}
+
+func codecByMediaType(contentType string) (Codec, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case ContentTypeJSON:
+		return JSONCodec, true
+	case ContentTypeProtobuf, "application/protobuf", "application/vnd.google.protobuf":
+		return ProtobufCodec, true
+	case ContentTypeMsgPack, "application/x-msgpack", "application/vnd.msgpack":
+		return MsgPackCodec, true
+	default:
+		return nil, false
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonCodec) MarshalForm(form *MetricForm) ([]byte, error) {
+	return json.Marshal(form)
+}
+
+func (jsonCodec) UnmarshalForm(data []byte, form *MetricForm) error {
+	return json.NewDecoder(bytes.NewReader(data)).Decode(form)
+}
+
+func (jsonCodec) MarshalForms(forms []MetricForm) ([]byte, error) {
+	return json.Marshal(forms)
+}
+
+func (jsonCodec) UnmarshalForms(data []byte) ([]MetricForm, error) {
+	var forms []MetricForm
+
+	err := json.NewDecoder(bytes.NewReader(data)).Decode(&forms)
+
+	return forms, err
+}
+
+// msgpackCodec reuses the json struct tags so both encodings share the field names.
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string {
+	return ContentTypeMsgPack
+}
+
+func (c msgpackCodec) MarshalForm(form *MetricForm) ([]byte, error) {
+	return c.marshal(form)
+}
+
+func (c msgpackCodec) UnmarshalForm(data []byte, form *MetricForm) error {
+	return c.unmarshal(data, form)
+}
+
+func (c msgpackCodec) MarshalForms(forms []MetricForm) ([]byte, error) {
+	return c.marshal(forms)
+}
+
+func (c msgpackCodec) UnmarshalForms(data []byte) ([]MetricForm, error) {
+	var forms []MetricForm
+
+	err := c.unmarshal(data, &forms)
+
+	return forms, err
+}
+
+func (msgpackCodec) marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("encode msgpack error: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("decode msgpack error: %w", err)
+	}
+
+	return nil
+}
+
+// protobufCodec encodes the messages of api/metrics.proto without generated code.
+type protobufCodec struct{}
+
+const (
+	protoMetricTypeGauge   = 1
+	protoMetricTypeCounter = 2
+)
+
+func (protobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (protobufCodec) MarshalForm(form *MetricForm) ([]byte, error) {
+	return appendProtoMetric(nil, form), nil
+}
+
+func (protobufCodec) UnmarshalForm(data []byte, form *MetricForm) error {
+	return consumeProtoMetric(data, form)
+}
+
+func (protobufCodec) MarshalForms(forms []MetricForm) ([]byte, error) {
+	var buf []byte
+
+	for i := range forms {
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, appendProtoMetric(nil, &forms[i]))
+	}
+
+	return buf, nil
+}
+
+func (protobufCodec) UnmarshalForms(data []byte) ([]MetricForm, error) {
+	var forms []MetricForm
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, fmt.Errorf("decode protobuf metric list error: %w", protowire.ParseError(n))
+		}
+
+		data = data[n:]
+
+		if num == 1 && typ == protowire.BytesType {
+			var msg []byte
+
+			msg, n = protowire.ConsumeBytes(data)
+			if n >= 0 {
+				var form MetricForm
+				if err := consumeProtoMetric(msg, &form); err != nil {
+					return nil, err
+				}
+
+				forms = append(forms, form)
+			}
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+
+		if n < 0 {
+			return nil, fmt.Errorf("decode protobuf metric list error: %w", protowire.ParseError(n))
+		}
+
+		data = data[n:]
+	}
+
+	return forms, nil
+}
+
+func appendProtoMetric(buf []byte, form *MetricForm) []byte {
+	if form.ID != "" {
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendString(buf, form.ID)
+	}
+
+	var mType uint64
+
+	switch form.MType {
+	case MetricTypeGauge:
+		mType = protoMetricTypeGauge
+	case MetricTypeCounter:
+		mType = protoMetricTypeCounter
+	}
+
+	if mType != 0 {
+		buf = protowire.AppendTag(buf, 2, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, mType)
+	}
+
+	if form.Delta != nil {
+		buf = protowire.AppendTag(buf, 3, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(*form.Delta))
+	}
+
+	if form.Value != nil {
+		buf = protowire.AppendTag(buf, 4, protowire.Fixed64Type)
+		buf = protowire.AppendFixed64(buf, math.Float64bits(*form.Value))
+	}
+
+	if form.Meta != nil {
+		buf = protowire.AppendTag(buf, 5, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, appendProtoMeta(nil, form.Meta))
+	}
+
+	return buf
+}
+
+func appendProtoMeta(buf []byte, meta *MetricMeta) []byte {
+	for num, val := range [...]string{meta.Unit, meta.Help, meta.Owner} {
+		if val != "" {
+			buf = protowire.AppendTag(buf, protowire.Number(num+1), protowire.BytesType)
+			buf = protowire.AppendString(buf, val)
+		}
+	}
+
+	return buf
+}
+
+func consumeProtoMetric(data []byte, form *MetricForm) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fmt.Errorf("decode protobuf metric error: %w", protowire.ParseError(n))
+		}
+
+		data = data[n:]
+
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			form.ID, n = protowire.ConsumeString(data)
+		case num == 2 && typ == protowire.VarintType:
+			var mType uint64
+
+			mType, n = protowire.ConsumeVarint(data)
+
+			switch mType {
+			case protoMetricTypeGauge:
+				form.MType = MetricTypeGauge
+			case protoMetricTypeCounter:
+				form.MType = MetricTypeCounter
+			default:
+				form.MType = ""
+			}
+		case num == 3 && typ == protowire.VarintType:
+			var delta uint64
+
+			delta, n = protowire.ConsumeVarint(data)
+			form.Delta = new(int64)
+			*form.Delta = int64(delta)
+		case num == 4 && typ == protowire.Fixed64Type:
+			var value uint64
+
+			value, n = protowire.ConsumeFixed64(data)
+			form.Value = new(float64)
+			*form.Value = math.Float64frombits(value)
+		case num == 5 && typ == protowire.BytesType:
+			var msg []byte
+
+			msg, n = protowire.ConsumeBytes(data)
+			if n >= 0 {
+				form.Meta = new(MetricMeta)
+				consumeErr := consumeProtoMeta(msg, form.Meta)
+				if consumeErr != nil {
+					return consumeErr
+				}
+			}
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+
+		if n < 0 {
+			return fmt.Errorf("decode protobuf metric error: %w", protowire.ParseError(n))
+		}
+
+		data = data[n:]
+	}
+
+	return nil
+}
+
+func consumeProtoMeta(data []byte, meta *MetricMeta) error {
+	fields := [...]*string{&meta.Unit, &meta.Help, &meta.Owner}
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fmt.Errorf("decode protobuf metric meta error: %w", protowire.ParseError(n))
+		}
+
+		data = data[n:]
+
+		if num >= 1 && int(num) <= len(fields) && typ == protowire.BytesType {
+			*fields[num-1], n = protowire.ConsumeString(data)
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+
+		if n < 0 {
+			return fmt.Errorf("decode protobuf metric meta error: %w", protowire.ParseError(n))
+		}
+
+		data = data[n:]
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	delta := int64(-42)
+	value := 1.5
+	zero := 0.0
+	forms := []MetricForm{
+		{ID: "PollCount", MType: MetricTypeCounter, Delta: &delta},
+		{ID: "Alloc", MType: MetricTypeGauge, Value: &value, Meta: &MetricMeta{Unit: "bytes", Owner: "runtime"}},
+		{ID: "Idle", MType: MetricTypeGauge, Value: &zero},
+	}
+
+	tests := []struct {
+		name  string
+		codec Codec
+	}{
+		{name: "json", codec: JSONCodec},
+		{name: "protobuf", codec: ProtobufCodec},
+		{name: "msgpack", codec: MsgPackCodec},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, marshErr := tt.codec.MarshalForms(forms)
+			if marshErr != nil {
+				t.Fatalf("MarshalForms() error = %v", marshErr)
+			}
+
+			got, unmarshErr := tt.codec.UnmarshalForms(data)
+			if unmarshErr != nil {
+				t.Fatalf("UnmarshalForms() error = %v", unmarshErr)
+			}
+
+			if !reflect.DeepEqual(got, forms) {
+				t.Errorf("UnmarshalForms() = %+v, want %+v", got, forms)
+			}
+
+			single, _ := tt.codec.MarshalForm(&forms[1])
+
+			var form MetricForm
+			if err := tt.codec.UnmarshalForm(single, &form); err != nil || !reflect.DeepEqual(form, forms[1]) {
+				t.Errorf("UnmarshalForm() = %+v, %v, want %+v", form, err, forms[1])
+			}
+		})
+	}
+}
+
+// The golden messages were encoded by the protobuf runtime from the api/metrics.proto descriptor.
+func TestProtobufCodec_Golden(t *testing.T) {
+	delta := int64(42)
+	negative := int64(-1)
+	value := 1.5
+	zero := 0.0
+
+	pollCount := MetricForm{ID: "PollCount", MType: MetricTypeCounter, Delta: &delta}
+	idle := MetricForm{ID: "Idle", MType: MetricTypeGauge, Value: &zero}
+
+	tests := []struct {
+		name   string
+		forms  []MetricForm
+		list   bool
+		golden string
+	}{
+		{name: "counter", forms: []MetricForm{pollCount}, golden: "0a09506f6c6c436f756e741002182a"},
+		{
+			name:   "negative delta",
+			forms:  []MetricForm{{ID: "C", MType: MetricTypeCounter, Delta: &negative}},
+			golden: "0a0143100218ffffffffffffffffff01",
+		},
+		{
+			name: "gauge with meta",
+			forms: []MetricForm{
+				{ID: "Alloc", MType: MetricTypeGauge, Value: &value, Meta: &MetricMeta{Unit: "bytes", Owner: "runtime"}},
+			},
+			golden: "0a05416c6c6f63100121000000000000f83f2a100a0562797465731a0772756e74696d65",
+		},
+		{name: "zero gauge keeps its value", forms: []MetricForm{idle}, golden: "0a0449646c651001210000000000000000"},
+		{
+			name:   "metric list",
+			forms:  []MetricForm{pollCount, idle},
+			list:   true,
+			golden: "0a0f0a09506f6c6c436f756e741002182a0a110a0449646c651001210000000000000000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			golden, _ := hex.DecodeString(tt.golden)
+
+			var (
+				got []byte
+				err error
+			)
+
+			if tt.list {
+				got, err = ProtobufCodec.MarshalForms(tt.forms)
+			} else {
+				got, err = ProtobufCodec.MarshalForm(&tt.forms[0])
+			}
+
+			if err != nil || !bytes.Equal(got, golden) {
+				t.Errorf("marshal = %x, %v, want %s", got, err, tt.golden)
+			}
+
+			decoded := make([]MetricForm, 1)
+
+			if tt.list {
+				decoded, err = ProtobufCodec.UnmarshalForms(golden)
+			} else {
+				err = ProtobufCodec.UnmarshalForm(golden, &decoded[0])
+			}
+
+			if err != nil || !reflect.DeepEqual(decoded, tt.forms) {
+				t.Errorf("unmarshal = %+v, %v, want %+v", decoded, err, tt.forms)
+			}
+		})
+	}
+}
+
+func TestNegotiateCodec(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   Codec
+	}{
+		{name: "no accept header", accept: "", want: JSONCodec},
+		{name: "wildcard", accept: "*/*", want: JSONCodec},
+		{name: "protobuf", accept: "application/x-protobuf", want: ProtobufCodec},
+		{name: "preferred by quality", accept: "application/json;q=0.5, application/msgpack", want: MsgPackCodec},
+		{name: "refused type", accept: "application/msgpack;q=0", want: JSONCodec},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NegotiateCodec(tt.accept, JSONCodec); got != tt.want {
+				t.Errorf("NegotiateCodec() = %T, want %T", got, tt.want)
+			}
+		})
+	}
+}
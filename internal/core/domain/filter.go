@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+)
+
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type MetricFilter struct {
+	MType MetricType
+	Match string
+	Regex *regexp.Regexp
+}
+
+type MetricsPage struct {
+	Metrics    []MetricForm `json:"metrics"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+func NewMetricFilter(mType string, match string, expr string) (*MetricFilter, error) {
+	filter := &MetricFilter{MType: MetricType(mType), Match: match}
+
+	if mType != "" && !filter.MType.IsValid() {
+		return nil, fmt.Errorf("unknown metric type: %s", mType)
+	}
+
+	if match != "" {
+		if _, matchErr := path.Match(match, ""); matchErr != nil {
+			return nil, fmt.Errorf("invalid match pattern: %w", matchErr)
+		}
+	}
+
+	if expr != "" {
+		re, reErr := regexp.Compile(expr)
+		if reErr != nil {
+			return nil, fmt.Errorf("invalid regex: %w", reErr)
+		}
+
+		filter.Regex = re
+	}
+
+	return filter, nil
+}
+
+func (f *MetricFilter) IsMatch(mType MetricType, name string) bool {
+	if f.MType != "" && f.MType != mType {
+		return false
+	}
+
+	if f.Match != "" {
+		if ok, _ := path.Match(f.Match, name); !ok {
+			return false
+		}
+	}
+
+	if f.Regex != nil && !f.Regex.MatchString(name) {
+		return false
+	}
+
+	return true
+}
+
+// Find returns the metrics matching the filter ordered by type and name.
+func (m *Metrics) Find(filter *MetricFilter) []MetricForm {
+	var forms []MetricForm
+
+	for name, value := range m.GetCounters() {
+		if filter.IsMatch(MetricTypeCounter, name) {
+			delta := value
+			forms = append(forms, MetricForm{ID: name, MType: MetricTypeCounter, Delta: &delta})
+		}
+	}
+
+	for name, value := range m.GetGauges() {
+		if filter.IsMatch(MetricTypeGauge, name) {
+			val := value
+			forms = append(forms, MetricForm{ID: name, MType: MetricTypeGauge, Value: &val})
+		}
+	}
+
+	sort.Slice(forms, func(i, j int) bool {
+		return forms[i].sortKey() < forms[j].sortKey()
+	})
+
+	for i := range forms {
+		if meta, hasMeta := m.GetMeta(forms[i].MType, forms[i].ID); hasMeta {
+			forms[i].Meta = &meta
+		}
+	}
+
+	return forms
+}
+
+// Paginate cuts a page of at most limit forms following the cursor.
+// The forms must be ordered the way Find returns them.
+func Paginate(forms []MetricForm, cursor string, limit int) (*MetricsPage, error) {
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	start := 0
+
+	if cursor != "" {
+		after, decodeErr := base64.RawURLEncoding.DecodeString(cursor)
+		if decodeErr != nil {
+			return nil, ErrInvalidCursor
+		}
+
+		start = sort.Search(len(forms), func(i int) bool {
+			return forms[i].sortKey() > string(after)
+		})
+	}
+
+	end := min(start+limit, len(forms))
+	page := &MetricsPage{Metrics: forms[start:end]}
+
+	if page.Metrics == nil {
+		page.Metrics = []MetricForm{}
+	}
+
+	if end < len(forms) {
+		page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(forms[end-1].sortKey()))
+	}
+
+	return page, nil
+}
+
+func (f *MetricForm) sortKey() string {
+	return string(f.MType) + "/" + f.ID
+}
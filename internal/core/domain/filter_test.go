@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.SetGaugeValue("HeapAlloc", 1)
+	metrics.SetGaugeValue("HeapIdle", 2)
+	metrics.SetGaugeValue("Sys", 3)
+	metrics.AddCounterValue("PollCount", 4)
+
+	forms := metrics.Find(&MetricFilter{})
+
+	type args struct {
+		limit int
+	}
+	tests := []struct {
+		name string
+		args args
+		want [][]string
+	}{
+		{
+			name: "single page",
+			args: args{limit: 10},
+			want: [][]string{{"counter/PollCount", "gauge/HeapAlloc", "gauge/HeapIdle", "gauge/Sys"}},
+		},
+		{
+			name: "several pages",
+			args: args{limit: 3},
+			want: [][]string{
+				{"counter/PollCount", "gauge/HeapAlloc", "gauge/HeapIdle"},
+				{"gauge/Sys"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := ""
+			for i, want := range tt.want {
+				page, err := Paginate(forms, cursor, tt.args.limit)
+				if err != nil {
+					t.Fatalf("Paginate() error = %v", err)
+				}
+
+				got := make([]string, 0, len(page.Metrics))
+				for _, form := range page.Metrics {
+					got = append(got, form.sortKey())
+				}
+
+				if len(got) != len(want) {
+					t.Fatalf("Paginate() page %d = %v, want %v", i, got, want)
+				}
+				for j := range got {
+					if got[j] != want[j] {
+						t.Fatalf("Paginate() page %d = %v, want %v", i, got, want)
+					}
+				}
+
+				cursor = page.NextCursor
+			}
+
+			if cursor != "" {
+				t.Errorf("Paginate() last cursor = %q, want empty", cursor)
+			}
+		})
+	}
+}
+
+func TestMetricFilter_IsMatch(t *testing.T) {
+	type args struct {
+		mType MetricType
+		name  string
+	}
+	tests := []struct {
+		name   string
+		filter [3]string
+		args   args
+		want   bool
+	}{
+		{"glob", [3]string{"", "Heap*", ""}, args{MetricTypeGauge, "HeapAlloc"}, true},
+		{"glob miss", [3]string{"", "Heap*", ""}, args{MetricTypeGauge, "Sys"}, false},
+		{"type miss", [3]string{"counter", "", ""}, args{MetricTypeGauge, "HeapAlloc"}, false},
+		{"regex", [3]string{"gauge", "", "^(Heap|Stack)Inuse$"}, args{MetricTypeGauge, "StackInuse"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewMetricFilter(tt.filter[0], tt.filter[1], tt.filter[2])
+			if err != nil {
+				t.Fatalf("NewMetricFilter() error = %v", err)
+			}
+
+			if got := filter.IsMatch(tt.args.mType, tt.args.name); got != tt.want {
+				t.Errorf("IsMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package domain
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -15,27 +14,29 @@ const (
 	MetricTypeCounter = MetricType("counter")
 )
 
+func (t MetricType) IsValid() bool {
+	return t == MetricTypeGauge || t == MetricTypeCounter
+}
+
 type MetricForm struct {
-	ID    string     `json:"id"`              // имя метрики
-	MType MetricType `json:"type"`            // параметр, принимающий значение gauge или counter
-	Delta *int64     `json:"delta,omitempty"` // значение метрики в случае передачи counter
-	Value *float64   `json:"value,omitempty"` // значение метрики в случае передачи gauge
+	ID    string      `json:"id"`              // имя метрики
+	MType MetricType  `json:"type"`            // параметр, принимающий значение gauge или counter
+	Delta *int64      `json:"delta,omitempty"` // значение метрики в случае передачи counter
+	Value *float64    `json:"value,omitempty"` // значение метрики в случае передачи gauge
+	Meta  *MetricMeta `json:"meta,omitempty"`  // необязательные единицы измерения, описание и владелец
 }
 
+// NewFormByRequest decodes the body in the encoding of its content type, the body stays readable.
 func NewFormByRequest(r *http.Request) (*MetricForm, error) {
-	var bodyBuffer bytes.Buffer
-	r.Body = io.NopCloser(io.TeeReader(r.Body, &bodyBuffer))
-
 	form := MetricForm{}
 
-	decodeErr := json.NewDecoder(r.Body).Decode(&form)
-
-	r.Body = io.NopCloser(&bodyBuffer)
-
-	if errors.Is(decodeErr, io.EOF) {
-		decodeErr = nil
+	data, readErr := readBody(r)
+	if readErr != nil || len(data) == 0 {
+		return &form, readErr
 	}
 
+	decodeErr := CodecByContentType(r.Header.Get("Content-Type")).UnmarshalForm(data, &form)
+
 	return &form, decodeErr
 }
 
@@ -47,21 +48,50 @@ func (f *MetricForm) IsCounterType() bool {
 	return f.MType == MetricTypeCounter
 }
 
-const HashHeader = "HashSHA256"
-
-func NewFormArrayByRequest(req *http.Request) ([]MetricForm, error) {
-	var bodyBuffer bytes.Buffer
-	req.Body = io.NopCloser(io.TeeReader(req.Body, &bodyBuffer))
+// Validate reports whether the form carries a known type along with the matching value.
+func (f *MetricForm) Validate() error {
+	switch {
+	case f.ID == "":
+		return errEmptyMetricID
+	case f.IsGaugeType() && f.Value == nil:
+		return errMissingValue
+	case f.IsCounterType() && f.Delta == nil:
+		return errMissingDelta
+	case !f.MType.IsValid():
+		return errUnknownMetricType
+	}
 
-	var forms []MetricForm
+	return nil
+}
 
-	decodeErr := json.NewDecoder(req.Body).Decode(&forms)
+const (
+	HashHeader      = "HashSHA256"
+	HashKeyIDHeader = "HashKeyID"
+	// EncryptionHeader names the scheme the request body is encrypted with.
+	EncryptionHeader = "X-Encryption"
+)
 
-	req.Body = io.NopCloser(&bodyBuffer)
+var (
+	errEmptyMetricID     = errors.New("empty metric id")
+	errMissingValue      = errors.New("gauge value is missing")
+	errMissingDelta      = errors.New("counter delta is missing")
+	errUnknownMetricType = errors.New("unknown metric type")
+)
 
-	if errors.Is(decodeErr, io.EOF) {
-		decodeErr = nil
+func NewFormArrayByRequest(req *http.Request) ([]MetricForm, error) {
+	data, readErr := readBody(req)
+	if readErr != nil || len(data) == 0 {
+		return nil, readErr
 	}
 
-	return forms, decodeErr
+	return CodecByContentType(req.Header.Get("Content-Type")).UnmarshalForms(data)
+}
+
+// readBody reads the whole body and replaces it with a copy so it can be read again.
+func readBody(req *http.Request) ([]byte, error) {
+	data, readErr := io.ReadAll(req.Body)
+
+	req.Body = io.NopCloser(bytes.NewReader(data))
+
+	return data, readErr
 }
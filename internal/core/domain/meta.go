@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	MaxMetaUnitLen  = 32
+	MaxMetaHelpLen  = 1024
+	MaxMetaOwnerLen = 64
+)
+
+var ErrInvalidMetricMeta = errors.New("invalid metric metadata")
+
+// MetricMeta describes a series: the unit of its values, a help text and the owning team.
+type MetricMeta struct {
+	Unit  string `json:"unit,omitempty"`
+	Help  string `json:"help,omitempty"`
+	Owner string `json:"owner,omitempty"`
+}
+
+// MetricMetaForm annotates a single series through the metadata API.
+type MetricMetaForm struct {
+	ID    string     `json:"id"`
+	MType MetricType `json:"type"`
+	Meta  MetricMeta `json:"meta"`
+}
+
+func (m MetricMeta) IsEmpty() bool {
+	return m.Unit == "" && m.Help == "" && m.Owner == ""
+}
+
+// Merge returns a copy of m with the non-empty fields of other applied over it.
+func (m MetricMeta) Merge(other MetricMeta) MetricMeta {
+	if other.Unit != "" {
+		m.Unit = other.Unit
+	}
+
+	if other.Help != "" {
+		m.Help = other.Help
+	}
+
+	if other.Owner != "" {
+		m.Owner = other.Owner
+	}
+
+	return m
+}
+
+func (m MetricMeta) Validate() error {
+	switch {
+	case len(m.Unit) > MaxMetaUnitLen:
+		return fmt.Errorf("%w: unit is too long", ErrInvalidMetricMeta)
+	case len(m.Help) > MaxMetaHelpLen:
+		return fmt.Errorf("%w: help is too long", ErrInvalidMetricMeta)
+	case len(m.Owner) > MaxMetaOwnerLen:
+		return fmt.Errorf("%w: owner is too long", ErrInvalidMetricMeta)
+	case strings.ContainsAny(m.Unit+m.Owner, " \n\r"):
+		return fmt.Errorf("%w: unit and owner must not contain spaces", ErrInvalidMetricMeta)
+	case strings.ContainsAny(m.Help, "\n\r"):
+		return fmt.Errorf("%w: help must be a single line", ErrInvalidMetricMeta)
+	}
+
+	return nil
+}
+
+// SetMeta merges the metadata into the one stored for the series.
+func (m *Metrics) SetMeta(mType MetricType, metricName string, meta MetricMeta) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	if m.Meta == nil {
+		m.Meta = make(map[string]MetricMeta)
+	}
+
+	key := metaKey(mType, metricName)
+	m.Meta[key] = m.Meta[key].Merge(meta)
+}
+
+func (m *Metrics) GetMeta(mType MetricType, metricName string) (MetricMeta, bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	meta, hasMeta := m.Meta[metaKey(mType, metricName)]
+
+	return meta, hasMeta
+}
+
+// GetMetas returns the metadata of every annotated series ordered by type and name.
+func (m *Metrics) GetMetas() []MetricMetaForm {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	forms := make([]MetricMetaForm, 0, len(m.Meta))
+
+	for key, meta := range m.Meta {
+		mType, name, _ := strings.Cut(key, "/")
+		forms = append(forms, MetricMetaForm{ID: name, MType: MetricType(mType), Meta: meta})
+	}
+
+	sort.Slice(forms, func(i, j int) bool {
+		return metaKey(forms[i].MType, forms[i].ID) < metaKey(forms[j].MType, forms[j].ID)
+	})
+
+	return forms
+}
+
+func metaKey(mType MetricType, metricName string) string {
+	return string(mType) + "/" + metricName
+}
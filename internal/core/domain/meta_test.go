@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMetricMeta_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		meta MetricMeta
+		want error
+	}{
+		{
+			name: "annotated series",
+			meta: MetricMeta{Unit: "bytes", Help: "Bytes of allocated heap objects.", Owner: "runtime-team"},
+			want: nil,
+		},
+		{
+			name: "empty meta",
+			meta: MetricMeta{},
+			want: nil,
+		},
+		{
+			name: "unit with spaces",
+			meta: MetricMeta{Unit: "kilo bytes"},
+			want: ErrInvalidMetricMeta,
+		},
+		{
+			name: "multiline help",
+			meta: MetricMeta{Help: "first\nsecond"},
+			want: ErrInvalidMetricMeta,
+		},
+		{
+			name: "too long owner",
+			meta: MetricMeta{Owner: strings.Repeat("o", MaxMetaOwnerLen+1)},
+			want: ErrInvalidMetricMeta,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.meta.Validate(); !errors.Is(err, tt.want) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetrics_SetMeta(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.SetMeta(MetricTypeGauge, "Alloc", MetricMeta{Unit: "bytes", Help: "Heap bytes."})
+
+	tests := []struct {
+		name string
+		meta MetricMeta
+		want MetricMeta
+	}{
+		{
+			name: "owner is added",
+			meta: MetricMeta{Owner: "runtime-team"},
+			want: MetricMeta{Unit: "bytes", Help: "Heap bytes.", Owner: "runtime-team"},
+		},
+		{
+			name: "help is replaced",
+			meta: MetricMeta{Help: "Bytes of allocated heap objects."},
+			want: MetricMeta{Unit: "bytes", Help: "Bytes of allocated heap objects.", Owner: "runtime-team"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics.SetMeta(MetricTypeGauge, "Alloc", tt.meta)
+
+			if got, _ := metrics.GetMeta(MetricTypeGauge, "Alloc"); got != tt.want {
+				t.Errorf("GetMeta() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
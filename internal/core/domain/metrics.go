@@ -1,6 +1,16 @@
 package domain
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// CounterSampleRetention bounds how far back counter rates can be computed.
+	CounterSampleRetention = time.Hour
+	// CounterSampleResolution is the spacing samples are merged to, bounding the samples kept per counter.
+	CounterSampleResolution = time.Second
+)
 
 type Counter struct {
 	Name  string
@@ -12,9 +22,24 @@ type Gauge struct {
 	Value float64
 }
 
+type CounterSample struct {
+	At    time.Time
+	Value int64
+}
+
+type CounterRate struct {
+	Rate      float64   `json:"rate"`
+	Increase  int64     `json:"increase"`
+	Resets    int       `json:"resets"`
+	Window    string    `json:"window"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type Metrics struct {
-	Counters map[string]int64   `json:"counters"`
-	Gauges   map[string]float64 `json:"gauges"`
+	Counters map[string]int64      `json:"counters"`
+	Gauges   map[string]float64    `json:"gauges"`
+	Meta     map[string]MetricMeta `json:"meta,omitempty"`
+	samples  map[string][]CounterSample
 	mx       *sync.RWMutex
 }
 
@@ -22,19 +47,75 @@ func NewMetrics() *Metrics {
 	return &Metrics{
 		Counters: make(map[string]int64),
 		Gauges:   make(map[string]float64),
+		Meta:     make(map[string]MetricMeta),
+		samples:  make(map[string][]CounterSample),
 		mx:       new(sync.RWMutex),
 	}
 }
 
 func (m *Metrics) AddCounterValue(metricName string, value int64) {
-	curVal, hasValue := m.GetCounterValue(metricName)
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	now := time.Now()
+
+	curVal, hasValue := m.Counters[metricName]
 	if !hasValue {
-		m.setCounterValue(metricName, value)
+		m.recordCounterSample(metricName, CounterSample{At: now, Value: 0})
+	}
 
-		return
+	m.Counters[metricName] = curVal + value
+	m.recordCounterSample(metricName, CounterSample{At: now, Value: curVal + value})
+}
+
+// GetCounterRate computes the per-second increase of the counter over the window ending at now.
+// A sample lower than its predecessor is treated as a counter reset to zero,
+// so a negative delta counts as a reset and never makes the increase negative.
+func (m *Metrics) GetCounterRate(
+	metricName string,
+	window time.Duration,
+	now time.Time,
+) (*CounterRate, bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	if _, hasValue := m.Counters[metricName]; !hasValue {
+		return nil, false
+	}
+
+	rate := &CounterRate{Window: window.String()}
+	from := now.Add(-window)
+	samples := m.samples[metricName]
+
+	var prev *CounterSample
+
+	for i := range samples {
+		sample := &samples[i]
+		if sample.At.After(now) {
+			break
+		}
+
+		if prev != nil && sample.At.After(from) {
+			if sample.Value < prev.Value {
+				rate.Resets++
+				rate.Increase += max(sample.Value, 0)
+			} else {
+				rate.Increase += sample.Value - prev.Value
+			}
+		}
+
+		prev = sample
 	}
 
-	m.setCounterValue(metricName, curVal+value)
+	if len(samples) > 0 {
+		rate.UpdatedAt = samples[len(samples)-1].At
+	}
+
+	if window > 0 {
+		rate.Rate = float64(rate.Increase) / window.Seconds()
+	}
+
+	return rate, true
 }
 
 func (m *Metrics) GetCounterValue(metricName string) (int64, bool) {
@@ -62,6 +143,33 @@ func (m *Metrics) SetGaugeValue(metricName string, value float64) {
 	m.Gauges[metricName] = value
 }
 
+// Has reports whether the series of the given type exists.
+func (m *Metrics) Has(mType MetricType, metricName string) bool {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	switch mType {
+	case MetricTypeCounter:
+		_, ok := m.Counters[metricName]
+
+		return ok
+	case MetricTypeGauge:
+		_, ok := m.Gauges[metricName]
+
+		return ok
+	default:
+		return false
+	}
+}
+
+// Len returns the number of series.
+func (m *Metrics) Len() int {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	return len(m.Counters) + len(m.Gauges)
+}
+
 func (m *Metrics) GetGauges() map[string]float64 {
 	m.mx.RLock()
 	defer m.mx.RUnlock()
@@ -86,9 +194,28 @@ func (m *Metrics) GetCounters() map[string]int64 {
 	return mapCopy
 }
 
-func (m *Metrics) setCounterValue(metricName string, value int64) {
-	m.mx.Lock()
-	defer m.mx.Unlock()
+// recordCounterSample appends the sample and drops the ones older than the retention,
+// keeping the newest of them as the baseline. A sample closer than CounterSampleResolution
+// to the one before the last replaces the last one, so at most two samples per resolution step are kept.
+// The caller must hold the write lock.
+func (m *Metrics) recordCounterSample(metricName string, sample CounterSample) {
+	if m.samples == nil {
+		m.samples = make(map[string][]CounterSample)
+	}
+
+	samples := m.samples[metricName]
+	if n := len(samples); n > 1 && sample.At.Sub(samples[n-2].At) < CounterSampleResolution {
+		samples[n-1] = sample
+
+		return
+	}
+
+	samples = append(samples, sample)
+
+	expired := 0
+	for expired+1 < len(samples) && samples[expired+1].At.Before(sample.At.Add(-CounterSampleRetention)) {
+		expired++
+	}
 
-	m.Counters[metricName] = value
+	m.samples[metricName] = samples[expired:]
 }
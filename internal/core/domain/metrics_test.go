@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetrics_GetCounterRate(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type args struct {
+		values []int64
+		window time.Duration
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantRate   float64
+		wantResets int
+	}{
+		{
+			name:     "steady increase",
+			args:     args{values: []int64{0, 10, 20, 30}, window: 3 * time.Second},
+			wantRate: 10,
+		},
+		{
+			name:     "window cuts old samples",
+			args:     args{values: []int64{0, 100, 110, 120}, window: 2 * time.Second},
+			wantRate: 10,
+		},
+		{
+			name:       "reset",
+			args:       args{values: []int64{0, 10, 4, 14}, window: 3 * time.Second},
+			wantRate:   8,
+			wantResets: 1,
+		},
+		{
+			name:       "negative delta below zero",
+			args:       args{values: []int64{0, 10, -5, 5}, window: 3 * time.Second},
+			wantRate:   20.0 / 3,
+			wantResets: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := NewMetrics()
+			metrics.Counters["PollCount"] = tt.args.values[len(tt.args.values)-1]
+
+			for i, val := range tt.args.values {
+				metrics.recordCounterSample(
+					"PollCount",
+					CounterSample{At: start.Add(time.Duration(i) * time.Second), Value: val},
+				)
+			}
+
+			now := start.Add(time.Duration(len(tt.args.values)-1) * time.Second)
+
+			got, ok := metrics.GetCounterRate("PollCount", tt.args.window, now)
+			if !ok {
+				t.Fatal("GetCounterRate() counter not found")
+			}
+
+			if got.Rate != tt.wantRate || got.Resets != tt.wantResets {
+				t.Errorf(
+					"GetCounterRate() = %v/%v, want %v/%v",
+					got.Rate,
+					got.Resets,
+					tt.wantRate,
+					tt.wantResets,
+				)
+			}
+		})
+	}
+}
+
+func TestMetrics_recordCounterSample(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		updates      int
+		interval     time.Duration
+		wantSamples  int
+		wantIncrease int64
+	}{
+		{name: "spaced updates are kept", updates: 4, interval: time.Second, wantSamples: 4, wantIncrease: 3},
+		{name: "frequent updates are merged", updates: 100, interval: 10 * time.Millisecond, wantSamples: 2, wantIncrease: 99},
+		{
+			name:         "samples beyond the retention are dropped",
+			updates:      3 * 3600,
+			interval:     time.Second,
+			wantSamples:  3602,
+			wantIncrease: 3600,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := NewMetrics()
+
+			for i := range tt.updates {
+				metrics.Counters["PollCount"] = int64(i)
+				metrics.recordCounterSample(
+					"PollCount",
+					CounterSample{At: start.Add(time.Duration(i) * tt.interval), Value: int64(i)},
+				)
+			}
+
+			if got := len(metrics.samples["PollCount"]); got != tt.wantSamples {
+				t.Errorf("samples = %d, want %d", got, tt.wantSamples)
+			}
+
+			now := start.Add(time.Duration(tt.updates-1) * tt.interval)
+
+			rate, _ := metrics.GetCounterRate("PollCount", CounterSampleRetention, now)
+			if rate.Increase != tt.wantIncrease {
+				t.Errorf("GetCounterRate() increase = %d, want %d", rate.Increase, tt.wantIncrease)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+const (
+	DefaultMetricNamePattern = `^[A-Za-z_][A-Za-z0-9_.:-]*$`
+	DefaultMetricNameMaxLen  = 255
+)
+
+var (
+	ErrInvalidMetricName = errors.New("invalid metric name")
+	ErrTooManySeries     = errors.New("series limit reached")
+)
+
+// NamePolicy restricts metric names accepted on writes.
+type NamePolicy struct {
+	pattern *regexp.Regexp
+	maxLen  int
+}
+
+func NewNamePolicy(pattern string, maxLen int) (*NamePolicy, error) {
+	re, reErr := regexp.Compile(pattern)
+	if reErr != nil {
+		return nil, fmt.Errorf("compile metric name pattern error: %w", reErr)
+	}
+
+	return &NamePolicy{pattern: re, maxLen: maxLen}, nil
+}
+
+func (p *NamePolicy) Validate(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidMetricName)
+	}
+
+	if p.maxLen > 0 && len(name) > p.maxLen {
+		return fmt.Errorf("%w: %q is longer than %d", ErrInvalidMetricName, name, p.maxLen)
+	}
+
+	if !p.pattern.MatchString(name) {
+		return fmt.Errorf("%w: %q doesn't match %s", ErrInvalidMetricName, name, p.pattern)
+	}
+
+	return nil
+}
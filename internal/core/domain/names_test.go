@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNamePolicy_Validate(t *testing.T) {
+	policy, policyErr := NewNamePolicy(DefaultMetricNamePattern, 16)
+	if policyErr != nil {
+		t.Fatalf("NewNamePolicy() error = %v", policyErr)
+	}
+
+	tests := []struct {
+		name   string
+		metric string
+		want   error
+	}{
+		{name: "valid", metric: "HeapAlloc", want: nil},
+		{name: "dots and colons", metric: "http.req:total", want: nil},
+		{name: "empty", metric: "", want: ErrInvalidMetricName},
+		{name: "slash", metric: "disk/usage", want: ErrInvalidMetricName},
+		{name: "too long", metric: strings.Repeat("a", 17), want: ErrInvalidMetricName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := policy.Validate(tt.metric); !errors.Is(err, tt.want) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
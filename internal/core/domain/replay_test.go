@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNonceCache_Check(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	cache := NewNonceCache(time.Minute)
+
+	type args struct {
+		nonce string
+		at    time.Time
+		now   time.Time
+	}
+	tests := []struct {
+		name string
+		args args
+		want error
+	}{
+		{
+			name: "fresh request",
+			args: args{nonce: "nonce-0001", at: now, now: now},
+			want: nil,
+		},
+		{
+			name: "replayed nonce",
+			args: args{nonce: "nonce-0001", at: now, now: now.Add(10 * time.Second)},
+			want: ErrReplayedNonce,
+		},
+		{
+			name: "stale timestamp",
+			args: args{nonce: "nonce-0002", at: now.Add(-2 * time.Minute), now: now},
+			want: ErrStaleTimestamp,
+		},
+		{
+			name: "timestamp from the future",
+			args: args{nonce: "nonce-0003", at: now.Add(2 * time.Minute), now: now},
+			want: ErrStaleTimestamp,
+		},
+		{
+			name: "malformed nonce",
+			args: args{nonce: "short", at: now, now: now},
+			want: ErrInvalidNonce,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := cache.Check(tt.args.nonce, tt.args.at, tt.args.now); !errors.Is(err, tt.want) {
+				t.Errorf("Check() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SnapshotModeMerge   = "merge"
+	SnapshotModeReplace = "replace"
+)
+
+// Snapshot is the full metric set of a tenant as exported and imported by the snapshot API.
+type Snapshot struct {
+	Tenant   string                `json:"tenant"`
+	TakenAt  time.Time             `json:"taken_at"`
+	Counters map[string]int64      `json:"counters"`
+	Gauges   map[string]float64    `json:"gauges"`
+	Meta     map[string]MetricMeta `json:"meta,omitempty"`
+}
+
+// Snapshot copies the metric set.
+func (m *Metrics) Snapshot(tenant string, now time.Time) *Snapshot {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	snapshot := &Snapshot{
+		Tenant:   tenant,
+		TakenAt:  now,
+		Counters: make(map[string]int64, len(m.Counters)),
+		Gauges:   make(map[string]float64, len(m.Gauges)),
+		Meta:     make(map[string]MetricMeta, len(m.Meta)),
+	}
+
+	for name, value := range m.Counters {
+		snapshot.Counters[name] = value
+	}
+
+	for name, value := range m.Gauges {
+		snapshot.Gauges[name] = value
+	}
+
+	for key, meta := range m.Meta {
+		snapshot.Meta[key] = meta
+	}
+
+	return snapshot
+}
+
+// Merge sets the series of the snapshot, the series missing from it are kept as they are.
+func (m *Metrics) Merge(snapshot *Snapshot) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	now := time.Now()
+
+	for name, value := range snapshot.Counters {
+		if _, hasValue := m.Counters[name]; !hasValue {
+			m.recordCounterSample(name, CounterSample{At: now, Value: 0})
+		}
+
+		m.Counters[name] = value
+		m.recordCounterSample(name, CounterSample{At: now, Value: value})
+	}
+
+	for name, value := range snapshot.Gauges {
+		m.Gauges[name] = value
+	}
+
+	if m.Meta == nil {
+		m.Meta = make(map[string]MetricMeta, len(snapshot.Meta))
+	}
+
+	for key, meta := range snapshot.Meta {
+		m.Meta[key] = meta
+	}
+}
+
+// Len returns the number of series in the snapshot.
+func (s *Snapshot) Len() int {
+	return len(s.Counters) + len(s.Gauges)
+}
+
+// Validate checks the series names against the policy and the metadata,
+// which may only describe the series of the snapshot.
+func (s *Snapshot) Validate(policy *NamePolicy) error {
+	for name := range s.Counters {
+		if err := policy.Validate(name); err != nil {
+			return err
+		}
+	}
+
+	for name := range s.Gauges {
+		if err := policy.Validate(name); err != nil {
+			return err
+		}
+	}
+
+	for key, meta := range s.Meta {
+		mType, name, _ := strings.Cut(key, "/")
+		if !s.Has(MetricType(mType), name) {
+			return fmt.Errorf("%w: unknown series %q", ErrInvalidMetricMeta, key)
+		}
+
+		if err := meta.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Has reports whether the series of the given type is in the snapshot.
+func (s *Snapshot) Has(mType MetricType, metricName string) bool {
+	switch mType {
+	case MetricTypeCounter:
+		_, ok := s.Counters[metricName]
+
+		return ok
+	case MetricTypeGauge:
+		_, ok := s.Gauges[metricName]
+
+		return ok
+	default:
+		return false
+	}
+}
+
+type ChangeOp string
+
+const (
+	ChangeAdd    = ChangeOp("+")
+	ChangeUpdate = ChangeOp("~")
+	ChangeRemove = ChangeOp("-")
+)
+
+// SeriesChange describes how a series value differs between two snapshots.
+type SeriesChange struct {
+	Op    ChangeOp
+	MType MetricType
+	ID    string
+	From  string
+	To    string
+}
+
+func (c SeriesChange) String() string {
+	switch c.Op {
+	case ChangeAdd:
+		return fmt.Sprintf("%s %s %s %s", c.Op, c.MType, c.ID, c.To)
+	case ChangeRemove:
+		return fmt.Sprintf("%s %s %s %s", c.Op, c.MType, c.ID, c.From)
+	default:
+		return fmt.Sprintf("%s %s %s %s -> %s", c.Op, c.MType, c.ID, c.From, c.To)
+	}
+}
+
+// DiffSnapshots lists the series changes turning current into next ordered by type and name.
+func DiffSnapshots(current *Snapshot, next *Snapshot) []SeriesChange {
+	var changes []SeriesChange
+
+	changes = appendChanges(changes, MetricTypeCounter, current.Counters, next.Counters, func(v int64) string {
+		return strconv.FormatInt(v, 10)
+	})
+	changes = appendChanges(changes, MetricTypeGauge, current.Gauges, next.Gauges, func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	})
+
+	sort.Slice(changes, func(i, j int) bool {
+		return metaKey(changes[i].MType, changes[i].ID) < metaKey(changes[j].MType, changes[j].ID)
+	})
+
+	return changes
+}
+
+func appendChanges[V int64 | float64](
+	changes []SeriesChange,
+	mType MetricType,
+	current map[string]V,
+	next map[string]V,
+	format func(V) string,
+) []SeriesChange {
+	for name, value := range next {
+		prev, hasPrev := current[name]
+
+		switch {
+		case !hasPrev:
+			changes = append(changes, SeriesChange{Op: ChangeAdd, MType: mType, ID: name, To: format(value)})
+		case prev != value:
+			changes = append(changes, SeriesChange{
+				Op:    ChangeUpdate,
+				MType: mType,
+				ID:    name,
+				From:  format(prev),
+				To:    format(value),
+			})
+		}
+	}
+
+	for name, value := range current {
+		if _, hasNext := next[name]; !hasNext {
+			changes = append(changes, SeriesChange{Op: ChangeRemove, MType: mType, ID: name, From: format(value)})
+		}
+	}
+
+	return changes
+}
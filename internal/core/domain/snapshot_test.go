@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSnapshot_Validate(t *testing.T) {
+	policy, _ := NewNamePolicy(DefaultMetricNamePattern, DefaultMetricNameMaxLen)
+
+	tests := []struct {
+		name     string
+		snapshot Snapshot
+		want     error
+	}{
+		{
+			name: "valid snapshot",
+			snapshot: Snapshot{
+				Counters: map[string]int64{"PollCount": 5},
+				Gauges:   map[string]float64{"Alloc": 1.5},
+				Meta:     map[string]MetricMeta{"gauge/Alloc": {Unit: "bytes"}},
+			},
+			want: nil,
+		},
+		{
+			name:     "invalid series name",
+			snapshot: Snapshot{Gauges: map[string]float64{"bad name": 1}},
+			want:     ErrInvalidMetricName,
+		},
+		{
+			name: "meta of a series missing from the snapshot",
+			snapshot: Snapshot{
+				Gauges: map[string]float64{"Alloc": 1.5},
+				Meta:   map[string]MetricMeta{"counter/Alloc": {Unit: "bytes"}},
+			},
+			want: ErrInvalidMetricMeta,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.snapshot.Validate(policy); !errors.Is(err, tt.want) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetrics_Merge(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.AddCounterValue("PollCount", 3)
+	metrics.SetGaugeValue("Alloc", 1)
+	metrics.SetGaugeValue("Kept", 7)
+
+	metrics.Merge(&Snapshot{
+		Counters: map[string]int64{"PollCount": 10},
+		Gauges:   map[string]float64{"Alloc": 2.5},
+	})
+
+	tests := []struct {
+		name  string
+		mType MetricType
+		id    string
+		want  float64
+	}{
+		{name: "counter is set", mType: MetricTypeCounter, id: "PollCount", want: 10},
+		{name: "gauge is set", mType: MetricTypeGauge, id: "Alloc", want: 2.5},
+		{name: "missing series is kept", mType: MetricTypeGauge, id: "Kept", want: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got float64
+
+			if tt.mType == MetricTypeCounter {
+				v, _ := metrics.GetCounterValue(tt.id)
+				got = float64(v)
+			} else {
+				got, _ = metrics.GetGaugeValue(tt.id)
+			}
+
+			if got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	current := &Snapshot{
+		Counters: map[string]int64{"PollCount": 3, "Dropped": 1},
+		Gauges:   map[string]float64{"Alloc": 1.5},
+	}
+
+	tests := []struct {
+		name string
+		next *Snapshot
+		want []string
+	}{
+		{
+			name: "same snapshot",
+			next: current,
+			want: nil,
+		},
+		{
+			name: "added, updated and removed series",
+			next: &Snapshot{
+				Counters: map[string]int64{"PollCount": 5},
+				Gauges:   map[string]float64{"Alloc": 1.5, "HeapSys": 8},
+			},
+			want: []string{"- counter Dropped 1", "~ counter PollCount 3 -> 5", "+ gauge HeapSys 8"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, change := range DiffSnapshots(current, tt.next) {
+				got = append(got, change.String())
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DiffSnapshots() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
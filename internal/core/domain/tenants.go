@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+const (
+	DefaultTenant = "default"
+	TenantHeader  = "X-Tenant-ID"
+)
+
+type tenantKey string
+
+const tenantCtxKey = tenantKey("tenant")
+
+var (
+	tenantIDPattern    = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+	ErrInvalidTenantID = errors.New("invalid tenant id")
+	ErrTooManyTenants  = errors.New("too many tenants")
+)
+
+func ValidateTenantID(tenant string) error {
+	if !tenantIDPattern.MatchString(tenant) {
+		return ErrInvalidTenantID
+	}
+
+	return nil
+}
+
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantCtxKey, tenant)
+}
+
+// TenantFromContext returns the tenant resolved for the request or the default one.
+func TenantFromContext(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantCtxKey).(string); ok && tenant != "" {
+		return tenant
+	}
+
+	return DefaultTenant
+}
+
+// Tenants keeps an isolated metric set per tenant.
+type Tenants struct {
+	metrics map[string]*Metrics
+	mx      *sync.RWMutex
+}
+
+func NewTenants() *Tenants {
+	return &Tenants{
+		metrics: map[string]*Metrics{DefaultTenant: NewMetrics()},
+		mx:      new(sync.RWMutex),
+	}
+}
+
+// Get returns the tenant metrics, unknown tenants get an empty set that isn't kept.
+func (t *Tenants) Get(tenant string) *Metrics {
+	if metrics, ok := t.Lookup(tenant); ok {
+		return metrics
+	}
+
+	return NewMetrics()
+}
+
+func (t *Tenants) Lookup(tenant string) (*Metrics, bool) {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	metrics, ok := t.metrics[tenant]
+
+	return metrics, ok
+}
+
+// Create returns the tenant metrics, creating the tenant unless there are limit tenants already.
+// The limit of 0 means no limit.
+func (t *Tenants) Create(tenant string, limit int) (*Metrics, error) {
+	if metrics, ok := t.Lookup(tenant); ok {
+		return metrics, nil
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if metrics, ok := t.metrics[tenant]; ok {
+		return metrics, nil
+	}
+
+	if limit > 0 && len(t.metrics) >= limit {
+		return nil, fmt.Errorf("%w: %d of %d in use", ErrTooManyTenants, len(t.metrics), limit)
+	}
+
+	metrics := NewMetrics()
+	t.metrics[tenant] = metrics
+
+	return metrics, nil
+}
+
+func (t *Tenants) Set(tenant string, metrics *Metrics) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.metrics[tenant] = metrics
+}
+
+// IDs returns the known tenants in lexical order.
+func (t *Tenants) IDs() []string {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	ids := make([]string, 0, len(t.metrics))
+	for id := range t.metrics {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
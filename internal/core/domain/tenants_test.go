@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestTenants_Create(t *testing.T) {
+	tests := []struct {
+		name    string
+		tenant  string
+		limit   int
+		want    []string
+		wantErr error
+	}{
+		{name: "known tenant over the limit", tenant: DefaultTenant, limit: 1, want: []string{DefaultTenant}},
+		{name: "new tenant within the limit", tenant: "team-a", limit: 2, want: []string{DefaultTenant, "team-a"}},
+		{name: "new tenant without limit", tenant: "team-a", want: []string{DefaultTenant, "team-a"}},
+		{
+			name:    "new tenant over the limit",
+			tenant:  "team-a",
+			limit:   1,
+			want:    []string{DefaultTenant},
+			wantErr: ErrTooManyTenants,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenants := NewTenants()
+			tenants.Get("unknown").SetGaugeValue("Alloc", 1)
+
+			if _, err := tenants.Create(tt.tenant, tt.limit); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := tenants.IDs(); !slices.Equal(got, tt.want) {
+				t.Errorf("IDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"collector/internal/adapters/store"
+	"collector/internal/config"
+	"collector/internal/core/domain"
+)
+
+// resolvedAlertRetention is how long resolved alerts stay visible before they are forgotten.
+const resolvedAlertRetention = 15 * time.Minute
+
+type alertRulesFile struct {
+	Rules []domain.AlertRule `json:"rules"`
+}
+
+// Alerter periodically evaluates the alert rules against the current metrics
+// and tracks the pending, firing and resolved alerts.
+type Alerter struct {
+	logger   *slog.Logger
+	conf     *config.ServerConfig
+	store    store.Store
+	notifier *WebhookNotifier
+	rules    []domain.AlertRule
+	alerts   map[string]*domain.Alert
+	mx       *sync.RWMutex
+}
+
+func NewAlerter(
+	logger *slog.Logger,
+	conf *config.ServerConfig,
+	st store.Store,
+	notifier *WebhookNotifier,
+) (*Alerter, error) {
+	alerter := &Alerter{
+		logger:   logger,
+		conf:     conf,
+		store:    st,
+		notifier: notifier,
+		alerts:   make(map[string]*domain.Alert),
+		mx:       new(sync.RWMutex),
+	}
+
+	if conf.GetAlertRulesPath() == "" {
+		return alerter, nil
+	}
+
+	rules, rulesErr := LoadAlertRules(conf.GetAlertRulesPath())
+	if rulesErr != nil {
+		return nil, rulesErr
+	}
+
+	alerter.rules = rules
+
+	return alerter, nil
+}
+
+func LoadAlertRules(path string) ([]domain.AlertRule, error) {
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, fmt.Errorf("read alert rules file error: %w", readErr)
+	}
+
+	var file alertRulesFile
+	if decodeErr := json.Unmarshal(data, &file); decodeErr != nil {
+		return nil, fmt.Errorf("decode alert rules file error: %w", decodeErr)
+	}
+
+	names := make(map[string]struct{}, len(file.Rules))
+	for _, rule := range file.Rules {
+		if validateErr := rule.Validate(); validateErr != nil {
+			return nil, fmt.Errorf("invalid alert rule: %w", validateErr)
+		}
+
+		if _, exists := names[rule.Name]; exists {
+			return nil, fmt.Errorf("duplicate alert rule: %s", rule.Name)
+		}
+
+		names[rule.Name] = struct{}{}
+	}
+
+	return file.Rules, nil
+}
+
+func (a *Alerter) HasRules() bool {
+	return len(a.rules) > 0
+}
+
+func (a *Alerter) InitEvaluationTicker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				a.Evaluate(ctx, now)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Evaluate checks every rule against the metrics of every tenant at the given moment
+// and moves the alerts between states.
+func (a *Alerter) Evaluate(ctx context.Context, now time.Time) {
+	window := a.conf.GetRateWindowDuration()
+	seen := make(map[string]struct{})
+
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	for _, tenant := range a.store.GetTenants() {
+		metrics := a.store.GetMetrics(tenant)
+
+		for i := range a.rules {
+			rule := &a.rules[i]
+			if rule.Tenant != "" && rule.Tenant != tenant {
+				continue
+			}
+
+			filter := &domain.MetricFilter{MType: rule.Type, Match: rule.Match}
+
+			for _, form := range metrics.Find(filter) {
+				value, hasValue := ruleValue(metrics, rule, &form, window, now)
+				if !hasValue {
+					continue
+				}
+
+				key := tenant + "/" + rule.Name + "/" + string(form.MType) + "/" + form.ID
+				seen[key] = struct{}{}
+
+				breached, _ := rule.Compare(value)
+				a.transit(ctx, key, tenant, rule, &form, value, breached, now)
+			}
+		}
+	}
+
+	for key, alert := range a.alerts {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		a.transit(ctx, key, alert.Tenant, nil, nil, alert.Value, false, now)
+	}
+}
+
+// Alerts returns the tenant alerts ordered by rule and metric, optionally narrowed to a state.
+func (a *Alerter) Alerts(tenant string, state domain.AlertState) []domain.Alert {
+	a.mx.RLock()
+	defer a.mx.RUnlock()
+
+	alerts := make([]domain.Alert, 0, len(a.alerts))
+	for _, alert := range a.alerts {
+		if alert.Tenant == tenant && (state == "" || alert.State == state) {
+			alerts = append(alerts, *alert)
+		}
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Rule != alerts[j].Rule {
+			return alerts[i].Rule < alerts[j].Rule
+		}
+
+		return alerts[i].Metric < alerts[j].Metric
+	})
+
+	return alerts
+}
+
+func (a *Alerter) transit(
+	ctx context.Context,
+	key string,
+	tenant string,
+	rule *domain.AlertRule,
+	form *domain.MetricForm,
+	value float64,
+	breached bool,
+	now time.Time,
+) {
+	alert, exists := a.alerts[key]
+
+	if breached && (!exists || alert.State == domain.AlertStateResolved) {
+		alert = &domain.Alert{
+			Tenant:    tenant,
+			Rule:      rule.Name,
+			Metric:    form.ID,
+			Type:      form.MType,
+			State:     domain.AlertStatePending,
+			Op:        rule.Op,
+			Threshold: rule.Threshold,
+			ActiveAt:  now,
+		}
+		a.alerts[key] = alert
+		exists = true
+	}
+
+	if !exists {
+		return
+	}
+
+	alert.Value = value
+
+	switch {
+	case breached && alert.State == domain.AlertStatePending:
+		if now.Sub(alert.ActiveAt) >= time.Duration(rule.For) {
+			firedAt := now
+			alert.State, alert.FiredAt = domain.AlertStateFiring, &firedAt
+			a.onTransition(ctx, alert)
+		}
+	case !breached && alert.State == domain.AlertStatePending:
+		delete(a.alerts, key)
+	case !breached && alert.State == domain.AlertStateFiring:
+		resolvedAt := now
+		alert.State, alert.ResolvedAt = domain.AlertStateResolved, &resolvedAt
+		a.onTransition(ctx, alert)
+	case alert.State == domain.AlertStateResolved && now.Sub(*alert.ResolvedAt) > resolvedAlertRetention:
+		delete(a.alerts, key)
+	}
+}
+
+func (a *Alerter) onTransition(ctx context.Context, alert *domain.Alert) {
+	a.logger.InfoContext(
+		ctx,
+		"alert state changed",
+		slog.String("tenant", alert.Tenant),
+		slog.String("rule", alert.Rule),
+		slog.String("metric", alert.Metric),
+		slog.String("state", string(alert.State)),
+		slog.Float64("value", alert.Value),
+	)
+
+	if a.notifier != nil {
+		a.notifier.Notify(ctx, *alert)
+	}
+}
+
+func ruleValue(
+	metrics *domain.Metrics,
+	rule *domain.AlertRule,
+	form *domain.MetricForm,
+	window time.Duration,
+	now time.Time,
+) (float64, bool) {
+	switch {
+	case form.IsGaugeType():
+		if rule.IsRateSource() {
+			return 0, false
+		}
+
+		return *form.Value, true
+	case rule.IsRateSource():
+		rate, hasRate := metrics.GetCounterRate(form.ID, window, now)
+		if !hasRate {
+			return 0, false
+		}
+
+		return rate.Rate, true
+	default:
+		return float64(*form.Delta), true
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"collector/internal/adapters/store"
+	"collector/internal/config"
+	"collector/internal/core/domain"
+)
+
+func TestAlerter_Evaluate(t *testing.T) {
+	start := time.Now()
+	rule := domain.AlertRule{
+		Name:      "low-memory",
+		Match:     "FreeMemory",
+		Op:        "<",
+		Threshold: 100,
+		For:       domain.Duration(time.Minute),
+	}
+
+	tests := []struct {
+		name   string
+		values []float64
+		want   []domain.AlertState
+	}{
+		{
+			name:   "pending then firing then resolved",
+			values: []float64{50, 40, 500},
+			want: []domain.AlertState{
+				domain.AlertStatePending,
+				domain.AlertStateFiring,
+				domain.AlertStateResolved,
+			},
+		},
+		{
+			name:   "pending recovers before firing",
+			values: []float64{50, 500},
+			want:   []domain.AlertState{domain.AlertStatePending, ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenants := domain.NewTenants()
+			metrics := tenants.Get(domain.DefaultTenant)
+			alerter := &Alerter{
+				logger: slog.Default(),
+				conf:   &config.ServerConfig{RateWindow: 60},
+				store:  store.NewMemoryStorage(tenants),
+				rules:  []domain.AlertRule{rule},
+				alerts: make(map[string]*domain.Alert),
+				mx:     new(sync.RWMutex),
+			}
+
+			for i, value := range tt.values {
+				metrics.SetGaugeValue("FreeMemory", value)
+				alerter.Evaluate(context.Background(), start.Add(time.Duration(i)*time.Minute))
+
+				var got domain.AlertState
+				if alerts := alerter.Alerts(domain.DefaultTenant, ""); len(alerts) > 0 {
+					got = alerts[0].State
+				}
+
+				if got != tt.want[i] {
+					t.Errorf("Evaluate() step %d state = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"collector/internal/core/domain"
+)
+
+const subscriberBufferSize = 256
+
+type Subscription struct {
+	tenant  string
+	filter  *domain.MetricFilter
+	updates chan domain.MetricForm
+}
+
+// UpdateBroker fans accepted metric updates out to the stream subscribers.
+// A subscriber that can't keep up is dropped instead of blocking the publisher.
+type UpdateBroker struct {
+	logger      *slog.Logger
+	mx          *sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+func NewUpdateBroker(logger *slog.Logger) *UpdateBroker {
+	return &UpdateBroker{
+		logger:      logger,
+		mx:          new(sync.Mutex),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+func (b *UpdateBroker) Subscribe(tenant string, filter *domain.MetricFilter) *Subscription {
+	sub := &Subscription{
+		tenant:  tenant,
+		filter:  filter,
+		updates: make(chan domain.MetricForm, subscriberBufferSize),
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	b.subscribers[sub] = struct{}{}
+
+	return sub
+}
+
+func (b *UpdateBroker) Unsubscribe(sub *Subscription) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	b.remove(sub)
+}
+
+// Publish delivers the update to the subscribers of the tenant resolved for ctx.
+func (b *UpdateBroker) Publish(ctx context.Context, form domain.MetricForm) {
+	tenant := domain.TenantFromContext(ctx)
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	for sub := range b.subscribers {
+		if sub.tenant != tenant || !sub.filter.IsMatch(form.MType, form.ID) {
+			continue
+		}
+
+		select {
+		case sub.updates <- form:
+		default:
+			b.logger.WarnContext(ctx, "drop slow stream subscriber")
+			b.remove(sub)
+		}
+	}
+}
+
+// Close disconnects every subscriber.
+func (b *UpdateBroker) Close() {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+func (b *UpdateBroker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+
+	delete(b.subscribers, sub)
+	close(sub.updates)
+}
+
+func (s *Subscription) Updates() <-chan domain.MetricForm {
+	return s.updates
+}
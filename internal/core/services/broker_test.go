@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"collector/internal/core/domain"
+)
+
+func TestUpdateBroker_Publish(t *testing.T) {
+	tests := []struct {
+		name        string
+		tenant      string
+		match       string
+		published   int
+		wantUpdates int
+		wantDropped bool
+	}{
+		{name: "matching update", tenant: domain.DefaultTenant, match: "Alloc*", published: 1, wantUpdates: 1},
+		{name: "filtered by name", tenant: domain.DefaultTenant, match: "Frees*", published: 1},
+		{name: "another tenant", tenant: "team-a", match: "Alloc*", published: 1},
+		{
+			name:        "slow subscriber is dropped",
+			tenant:      domain.DefaultTenant,
+			match:       "Alloc*",
+			published:   subscriberBufferSize + 1,
+			wantUpdates: subscriberBufferSize,
+			wantDropped: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := NewUpdateBroker(slog.Default())
+
+			filter, filterErr := domain.NewMetricFilter("", tt.match, "")
+			if filterErr != nil {
+				t.Fatal(filterErr)
+			}
+
+			sub := broker.Subscribe(tt.tenant, filter)
+			defer broker.Unsubscribe(sub)
+
+			for range tt.published {
+				broker.Publish(context.Background(), gaugeForm("Alloc"))
+			}
+
+			updates, dropped := 0, false
+
+		drain:
+			for {
+				select {
+				case _, ok := <-sub.Updates():
+					if !ok {
+						dropped = true
+
+						break drain
+					}
+
+					updates++
+				default:
+					break drain
+				}
+			}
+
+			if updates != tt.wantUpdates || dropped != tt.wantDropped {
+				t.Errorf("updates, dropped = %d, %v, want %d, %v", updates, dropped, tt.wantUpdates, tt.wantDropped)
+			}
+		})
+	}
+}
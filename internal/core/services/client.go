@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+)
+
+var ErrInvalidResponseSign = errors.New("invalid response signature")
+
+// StatusError reports a response with a non-2xx status.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" || e.Body == http.StatusText(e.Code) {
+		return http.StatusText(e.Code)
+	}
+
+	return fmt.Sprintf("%s: %s", http.StatusText(e.Code), e.Body)
+}
+
+// Client calls the server API with the requests signed, compressed and encrypted the way the agent sends them.
+type Client struct {
+	conf       *config.AgentConfig
+	httpClient *http.Client
+	realIP     string
+}
+
+func NewClient(conf *config.AgentConfig, realIP string) *Client {
+	return &Client{
+		conf:       conf,
+		httpClient: newHTTPClient(conf),
+		realIP:     realIP,
+	}
+}
+
+// NewClientWithTransport returns the client sending the requests through the transport,
+// the transport is expected to carry the TLS settings of the config.
+func NewClientWithTransport(conf *config.AgentConfig, realIP string, transport http.RoundTripper) *Client {
+	return &Client{
+		conf:       conf,
+		httpClient: &http.Client{Transport: transport},
+		realIP:     realIP,
+	}
+}
+
+// NewRequest builds the request to the server path, data is sent in the configured encoding.
+func (c *Client) NewRequest(ctx context.Context, method string, path string, data []byte) (*http.Request, error) {
+	url := fmt.Sprintf("%s://%s%s", c.conf.GetHTTPScheme(), c.conf.GetAddress(), path)
+
+	return buildRequest(ctx, c.conf, method, url, c.realIP, data)
+}
+
+// Do sends the request, the caller has to close the response body.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request error: %w", err)
+	}
+
+	return resp, nil
+}
+
+// Call sends the request and returns the response body once its signature is verified.
+func (c *Client) Call(ctx context.Context, method string, path string, data []byte) ([]byte, error) {
+	body, _, err := c.call(ctx, method, path, data, "")
+
+	return body, err
+}
+
+// Update sends a single metric and returns the stored state of it.
+func (c *Client) Update(ctx context.Context, form *domain.MetricForm) (*domain.MetricForm, error) {
+	return c.callForm(ctx, "/update/", form)
+}
+
+// Value returns the current state of the metric.
+func (c *Client) Value(ctx context.Context, mType domain.MetricType, id string) (*domain.MetricForm, error) {
+	return c.callForm(ctx, "/value/", &domain.MetricForm{ID: id, MType: mType})
+}
+
+// Send posts the metric to its update path endpoint the way the agent reports it, without retries.
+func (c *Client) Send(ctx context.Context, form *domain.MetricForm) error {
+	req, reqErr := newMetricRequest(ctx, c.conf, c.realIP, form)
+	if reqErr != nil {
+		return reqErr
+	}
+
+	result, sendErr := sendRequest(c.httpClient, req)
+	if sendErr != nil {
+		return sendErr
+	}
+
+	if result.Code < http.StatusOK || result.Code >= http.StatusMultipleChoices {
+		return &StatusError{Code: result.Code, Body: strings.TrimSpace(result.Body)}
+	}
+
+	return nil
+}
+
+// UpdateBatch sends the metrics in a single request.
+func (c *Client) UpdateBatch(ctx context.Context, forms []domain.MetricForm) error {
+	data, marshErr := c.conf.GetCodec().MarshalForms(forms)
+	if marshErr != nil {
+		return fmt.Errorf("marshall data error: %w", marshErr)
+	}
+
+	_, _, err := c.call(ctx, http.MethodPost, "/updates/", data, "")
+
+	return err
+}
+
+func (c *Client) callForm(ctx context.Context, path string, form *domain.MetricForm) (*domain.MetricForm, error) {
+	data, marshErr := c.conf.GetCodec().MarshalForm(form)
+	if marshErr != nil {
+		return nil, fmt.Errorf("marshall data error: %w", marshErr)
+	}
+
+	body, header, err := c.call(ctx, http.MethodPost, path, data, c.conf.GetCodec().ContentType())
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(domain.MetricForm)
+
+	if decodeErr := domain.CodecByContentType(header.Get("Content-Type")).UnmarshalForm(body, result); decodeErr != nil {
+		return nil, fmt.Errorf("decode response error: %w", decodeErr)
+	}
+
+	return result, nil
+}
+
+func (c *Client) call(
+	ctx context.Context,
+	method string,
+	path string,
+	data []byte,
+	accept string,
+) ([]byte, http.Header, error) {
+	req, reqErr := c.NewRequest(ctx, method, path, data)
+	if reqErr != nil {
+		return nil, nil, fmt.Errorf("build request error: %w", reqErr)
+	}
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, respErr := c.Do(req)
+	if respErr != nil {
+		return nil, nil, respErr
+	}
+
+	defer resp.Body.Close()
+
+	body, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		return nil, nil, fmt.Errorf("read body error: %w", readErr)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, nil, &StatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+
+	// With keys configured an unsigned response is rejected too, a stripped header mustn't skip the check.
+	if keySet := c.conf.GetKeySet(); !keySet.IsEmpty() {
+		sign := resp.Header.Get(domain.HashHeader)
+		if sign == "" || !keySet.Verify(string(body), sign, resp.Header.Get(domain.HashKeyIDHeader)) {
+			return nil, nil, ErrInvalidResponseSign
+		}
+	}
+
+	return body, resp.Header, nil
+}
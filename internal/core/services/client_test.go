@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/pkg/hashing"
+)
+
+func TestClient_Call(t *testing.T) {
+	const (
+		requestKey = "old"
+		body       = `{"metrics":[]}`
+	)
+
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		signKey   string
+		signKeyID string
+		wantErr   error
+	}{
+		{name: "unsigned response", status: http.StatusOK, body: body, wantErr: ErrInvalidResponseSign},
+		{name: "response signed by the request key", status: http.StatusOK, body: body, signKey: requestKey},
+		{
+			name:      "response signed by a rotated key",
+			status:    http.StatusOK,
+			body:      body,
+			signKey:   "new",
+			signKeyID: "k2",
+		},
+		{
+			name:      "response signed by an unknown key",
+			status:    http.StatusOK,
+			body:      body,
+			signKey:   "other",
+			signKeyID: "k3",
+			wantErr:   ErrInvalidResponseSign,
+		},
+		{
+			name:    "error status",
+			status:  http.StatusNotFound,
+			body:    "metric not found\n",
+			wantErr: &StatusError{Code: http.StatusNotFound, Body: "metric not found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+				data, _ := io.ReadAll(req.Body)
+				signed := domain.RequestSignaturePayload(
+					req.Method,
+					req.URL.RequestURI(),
+					req.Header.Get(domain.TimestampHeader),
+					req.Header.Get(domain.NonceHeader),
+					data,
+				)
+
+				if !hashing.Verify(signed, req.Header.Get(domain.HashHeader), requestKey) {
+					http.Error(writer, "request signature mismatch", http.StatusBadRequest)
+
+					return
+				}
+
+				if tt.signKey != "" {
+					writer.Header().Set(domain.HashHeader, hashing.HashByKey(tt.body, tt.signKey))
+					writer.Header().Set(domain.HashKeyIDHeader, tt.signKeyID)
+				}
+
+				writer.WriteHeader(tt.status)
+				_, _ = io.WriteString(writer, tt.body)
+			}))
+			t.Cleanup(srv.Close)
+
+			conf, confErr := config.NewAgentConfig(&config.FlagContainer{
+				AppType:   config.AppTypeAgent,
+				Address:   strings.TrimPrefix(srv.URL, "http://"),
+				Transport: config.TransportHTTP,
+				Encoding:  domain.EncodingJSON,
+				HashKey:   requestKey,
+				HashKeys:  "k2:new",
+			}, &config.EnvContainer{})
+			if confErr != nil {
+				t.Fatal(confErr)
+			}
+
+			got, err := NewClient(conf, "").Call(context.Background(), http.MethodPost, "/updates/", []byte("[]"))
+
+			var statusErr *StatusError
+			switch {
+			case errors.As(tt.wantErr, &statusErr):
+				var gotStatus *StatusError
+				if !errors.As(err, &gotStatus) || *gotStatus != *statusErr {
+					t.Fatalf("Call() error = %v, want %v", err, tt.wantErr)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Fatalf("Call() error = %v, want %v", err, tt.wantErr)
+			case err == nil && string(got) != tt.body:
+				t.Errorf("Call() = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"collector/internal/adapters/keystore"
+	"collector/internal/config"
+	"collector/internal/core/domain"
+)
+
+const bootstrapAdminKeyID = "bootstrap-admin"
+
+// KeyService authenticates API keys and manages them in the key store.
+// The bootstrap admin key from the config is checked in memory and never persisted.
+type KeyService struct {
+	logger    *slog.Logger
+	conf      *config.ServerConfig
+	store     keystore.KeyStore
+	bootstrap *domain.APIKey
+}
+
+func NewKeyService(
+	logger *slog.Logger,
+	conf *config.ServerConfig,
+	store keystore.KeyStore,
+) *KeyService {
+	service := &KeyService{logger: logger, conf: conf, store: store}
+
+	if adminKey := conf.GetAdminAPIKey(); adminKey != "" {
+		service.bootstrap = &domain.APIKey{
+			ID:     bootstrapAdminKeyID,
+			Hash:   domain.HashAPIKey(adminKey),
+			Scopes: []domain.Scope{domain.ScopeAdmin},
+		}
+	}
+
+	return service
+}
+
+func (ks *KeyService) IsEnabled() bool {
+	return ks.conf.IsAuthEnabled()
+}
+
+func (ks *KeyService) Authenticate(ctx context.Context, secret string) (*domain.APIKey, error) {
+	hash := domain.HashAPIKey(secret)
+
+	if ks.bootstrap != nil && ks.bootstrap.Hash == hash {
+		return ks.bootstrap, nil
+	}
+
+	key, err := ks.store.Find(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("authenticate api key error: %w", err)
+	}
+
+	return key, nil
+}
+
+// Create generates a new key and returns it along with its secret, which is shown only once.
+// A caller bound to a tenant can only create keys of that tenant.
+func (ks *KeyService) Create(
+	ctx context.Context,
+	callerTenant string,
+	scopes []domain.Scope,
+	tenant string,
+) (*domain.APIKey, string, error) {
+	if callerTenant != "" {
+		if tenant != "" && tenant != callerTenant {
+			return nil, "", domain.ErrForeignTenant
+		}
+
+		tenant = callerTenant
+	}
+
+	key, secret, keyErr := domain.NewAPIKey(scopes, tenant)
+	if keyErr != nil {
+		return nil, "", keyErr
+	}
+
+	if addErr := ks.store.Add(ctx, key); addErr != nil {
+		return nil, "", fmt.Errorf("add api key error: %w", addErr)
+	}
+
+	ks.logger.InfoContext(ctx, "api key created", slog.String("key_id", key.ID))
+
+	return key, secret, nil
+}
+
+// List returns the stored keys visible to the caller without their hashes,
+// a caller bound to a tenant only sees the keys of that tenant.
+func (ks *KeyService) List(ctx context.Context, callerTenant string) ([]domain.APIKey, error) {
+	keys, err := ks.store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys error: %w", err)
+	}
+
+	visible := make([]domain.APIKey, 0, len(keys))
+
+	for _, key := range keys {
+		if callerTenant != "" && key.Tenant != callerTenant {
+			continue
+		}
+
+		key.Hash = ""
+		visible = append(visible, key)
+	}
+
+	return visible, nil
+}
+
+// Revoke deletes the key, the keys of other tenants are reported as missing to a caller bound to a tenant.
+func (ks *KeyService) Revoke(ctx context.Context, callerTenant string, id string) error {
+	if callerTenant != "" {
+		keys, listErr := ks.List(ctx, callerTenant)
+		if listErr != nil {
+			return fmt.Errorf("revoke api key error: %w", listErr)
+		}
+
+		if !slices.ContainsFunc(keys, func(key domain.APIKey) bool { return key.ID == id }) {
+			return domain.ErrAPIKeyNotFound
+		}
+	}
+
+	if err := ks.store.Delete(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("revoke api key error: %w", err)
+	}
+
+	ks.logger.InfoContext(ctx, "api key revoked", slog.String("key_id", id))
+
+	return nil
+}
+
+func (ks *KeyService) Close() error {
+	return ks.store.Close()
+}
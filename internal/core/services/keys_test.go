@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"collector/internal/adapters/keystore"
+	"collector/internal/config"
+	"collector/internal/core/domain"
+)
+
+func TestKeyService_TenantScope(t *testing.T) {
+	ctx := context.Background()
+	keys := NewKeyService(slog.Default(), &config.ServerConfig{AuthEnabled: true}, keystore.NewMemoryKeyStore())
+
+	global, _, _ := keys.Create(ctx, "", []domain.Scope{domain.ScopeRead}, "")
+	other, _, _ := keys.Create(ctx, "", []domain.Scope{domain.ScopeRead}, "other")
+
+	tests := []struct {
+		name       string
+		caller     string
+		tenant     string
+		wantTenant string
+		wantErr    error
+	}{
+		{name: "global caller creates a key of any tenant", tenant: "other", wantTenant: "other"},
+		{name: "tenant caller gets a key of its tenant", caller: "team-a", wantTenant: "team-a"},
+		{
+			name:    "tenant caller can't create a key of another tenant",
+			caller:  "team-a",
+			tenant:  "other",
+			wantErr: domain.ErrForeignTenant,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, _, err := keys.Create(ctx, tt.caller, []domain.Scope{domain.ScopeAdmin}, tt.tenant)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && key.Tenant != tt.wantTenant {
+				t.Errorf("Create() tenant = %q, want %q", key.Tenant, tt.wantTenant)
+			}
+		})
+	}
+
+	list, _ := keys.List(ctx, "team-a")
+	if len(list) != 1 {
+		t.Errorf("List() returned %d keys, want 1", len(list))
+	}
+
+	for _, key := range list {
+		if key.Tenant != "team-a" {
+			t.Errorf("List() returned key %s of tenant %q", key.ID, key.Tenant)
+		}
+	}
+
+	for _, id := range []string{global.ID, other.ID} {
+		if err := keys.Revoke(ctx, "team-a", id); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+			t.Errorf("Revoke(%s) error = %v, want %v", id, err, domain.ErrAPIKeyNotFound)
+		}
+	}
+}
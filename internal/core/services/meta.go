@@ -0,0 +1,50 @@
+package services
+
+import "collector/internal/core/domain"
+
+// agentMetricMeta annotates the metrics collected by the agent.
+var agentMetricMeta = map[string]domain.MetricMeta{
+	"Alloc":           {Unit: "bytes", Help: "Bytes of allocated heap objects."},
+	"BuckHashSys":     {Unit: "bytes", Help: "Bytes of memory in profiling bucket hash tables."},
+	"Frees":           {Help: "Cumulative count of heap objects freed."},
+	"GCCPUFraction":   {Unit: "ratio", Help: "Fraction of available CPU time used by the GC since the program started."},
+	"GCSys":           {Unit: "bytes", Help: "Bytes of memory in garbage collection metadata."},
+	"HeapAlloc":       {Unit: "bytes", Help: "Bytes of allocated heap objects."},
+	"HeapIdle":        {Unit: "bytes", Help: "Bytes in idle (unused) heap spans."},
+	"HeapInuse":       {Unit: "bytes", Help: "Bytes in in-use heap spans."},
+	"HeapObjects":     {Help: "Number of allocated heap objects."},
+	"HeapReleased":    {Unit: "bytes", Help: "Bytes of physical memory returned to the OS."},
+	"HeapSys":         {Unit: "bytes", Help: "Bytes of heap memory obtained from the OS."},
+	"LastGC":          {Unit: "nanoseconds", Help: "Time the last garbage collection finished, since the Unix epoch."},
+	"Lookups":         {Help: "Number of pointer lookups performed by the runtime."},
+	"MCacheInuse":     {Unit: "bytes", Help: "Bytes of allocated mcache structures."},
+	"MCacheSys":       {Unit: "bytes", Help: "Bytes of memory obtained from the OS for mcache structures."},
+	"MSpanInuse":      {Unit: "bytes", Help: "Bytes of allocated mspan structures."},
+	"MSpanSys":        {Unit: "bytes", Help: "Bytes of memory obtained from the OS for mspan structures."},
+	"Mallocs":         {Help: "Cumulative count of heap objects allocated."},
+	"NextGC":          {Unit: "bytes", Help: "Target heap size of the next GC cycle."},
+	"NumForcedGC":     {Help: "Number of GC cycles forced by the application calling GC."},
+	"NumGC":           {Help: "Number of completed GC cycles."},
+	"OtherSys":        {Unit: "bytes", Help: "Bytes of memory in miscellaneous off-heap runtime allocations."},
+	"PauseTotalNs":    {Unit: "nanoseconds", Help: "Cumulative time spent in GC stop-the-world pauses."},
+	"StackInuse":      {Unit: "bytes", Help: "Bytes in stack spans."},
+	"StackSys":        {Unit: "bytes", Help: "Bytes of stack memory obtained from the OS."},
+	"Sys":             {Unit: "bytes", Help: "Total bytes of memory obtained from the OS."},
+	"TotalAlloc":      {Unit: "bytes", Help: "Cumulative bytes allocated for heap objects."},
+	"RandomValue":     {Help: "Random value refreshed on every poll."},
+	"TotalMemory":     {Unit: "bytes", Help: "Total amount of host RAM."},
+	"FreeMemory":      {Unit: "bytes", Help: "Amount of host RAM not used at all."},
+	"CPUutilization1": {Unit: "percent", Help: "Utilization of the first CPU core."},
+	"PollCount":       {Help: "Number of polls since the previous report."},
+}
+
+// withMeta attaches the known metadata to the forms.
+func withMeta(forms []*domain.MetricForm) []*domain.MetricForm {
+	for _, form := range forms {
+		if meta, hasMeta := agentMetricMeta[form.ID]; hasMeta {
+			form.Meta = &meta
+		}
+	}
+
+	return forms
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"collector/internal/adapters/store"
+	"collector/internal/core/domain"
+)
+
+// ErrEmptySource is returned when the source store has no series, a mistyped source would wipe the target.
+var ErrEmptySource = errors.New("source store has no series")
+
+// MigrateOptions tunes a migration.
+type MigrateOptions struct {
+	// DryRun reports the changes without saving the target.
+	DryRun bool
+	// AllowEmpty lets an empty source replace the tenants of the target.
+	AllowEmpty bool
+}
+
+// TenantDiff lists the changes a migration makes to a tenant of the target store.
+type TenantDiff struct {
+	Tenant  string
+	Changes []domain.SeriesChange
+}
+
+// Migrate restores both stores and replaces every tenant of the target with the one of the source,
+// the tenants the source doesn't have are kept. The target is only saved when it isn't a dry run.
+func Migrate(ctx context.Context, from store.Store, to store.Store, opts MigrateOptions) ([]TenantDiff, error) {
+	if err := from.Restore(ctx); err != nil {
+		return nil, fmt.Errorf("restore source store error: %w", err)
+	}
+
+	if !opts.AllowEmpty && countSeries(from) == 0 {
+		return nil, ErrEmptySource
+	}
+
+	if err := to.Restore(ctx); err != nil {
+		return nil, fmt.Errorf("restore target store error: %w", err)
+	}
+
+	now := time.Now()
+	diffs := make([]TenantDiff, 0, len(from.GetTenants()))
+
+	for _, tenant := range from.GetTenants() {
+		next := from.GetMetrics(tenant).Snapshot(tenant, now)
+		current := to.GetMetrics(tenant).Snapshot(tenant, now)
+
+		diffs = append(diffs, TenantDiff{Tenant: tenant, Changes: domain.DiffSnapshots(current, next)})
+
+		metrics := domain.NewMetrics()
+		metrics.Merge(next)
+		to.SetMetrics(tenant, metrics)
+	}
+
+	if opts.DryRun {
+		return diffs, nil
+	}
+
+	if err := to.Save(ctx); err != nil {
+		return nil, fmt.Errorf("save target store error: %w", err)
+	}
+
+	return diffs, nil
+}
+
+func countSeries(st store.Store) int {
+	total := 0
+	for _, tenant := range st.GetTenants() {
+		total += st.GetMetrics(tenant).Len()
+	}
+
+	return total
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"collector/internal/adapters/store"
+	"collector/internal/config"
+	"collector/internal/core/domain"
+)
+
+func newTestFileStore(t *testing.T, path string) *store.FileStorage {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	st, err := store.NewFileStorage(logger, &config.ServerConfig{FileStoragePath: path}, domain.NewTenants())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return st
+}
+
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir()
+
+	saved := filepath.Join(dir, "saved.json")
+	source := newTestFileStore(t, saved)
+	source.GetMetrics(domain.DefaultTenant).SetGaugeValue("Alloc", 2)
+
+	if err := source.Save(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	notDir := filepath.Join(dir, "file")
+	if err := os.WriteFile(notDir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		from       string
+		opts       MigrateOptions
+		wantErr    error
+		wantAnyErr bool
+		wantTarget int
+	}{
+		{name: "copies the source", from: saved, wantTarget: 1},
+		{name: "missing source", from: filepath.Join(dir, "missing.json"), wantErr: ErrEmptySource, wantTarget: 2},
+		{
+			name:       "missing source allowed to be empty",
+			from:       filepath.Join(dir, "missing.json"),
+			opts:       MigrateOptions{AllowEmpty: true},
+			wantTarget: 0,
+		},
+		{name: "unreadable source", from: filepath.Join(notDir, "saved.json"), wantAnyErr: true, wantTarget: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenants := domain.NewTenants()
+			target := tenants.Get(domain.DefaultTenant)
+			target.SetGaugeValue("Alloc", 1)
+			target.SetGaugeValue("Frees", 1)
+
+			_, err := Migrate(context.Background(), newTestFileStore(t, tt.from), store.NewMemoryStorage(tenants), tt.opts)
+
+			switch {
+			case tt.wantAnyErr && err == nil:
+				t.Fatal("Migrate() error = nil, want an error")
+			case !tt.wantAnyErr && !errors.Is(err, tt.wantErr):
+				t.Fatalf("Migrate() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := tenants.Get(domain.DefaultTenant).Len(); got != tt.wantTarget {
+				t.Errorf("target series = %d, want %d", got, tt.wantTarget)
+			}
+		})
+	}
+}
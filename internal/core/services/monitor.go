@@ -14,6 +14,7 @@ import (
 
 	"collector/internal/config"
 	"collector/internal/core/domain"
+	"collector/pkg/network"
 	"github.com/shirou/gopsutil/cpu"
 	"github.com/shirou/gopsutil/mem"
 	"golang.org/x/sync/errgroup"
@@ -26,15 +27,36 @@ type Monitor struct {
 	agentConfig *config.AgentConfig
 	mx          *sync.RWMutex
 	httpClient  *http.Client
+	wsClient    *WSClient
+	realIP      string
 }
 
 func NewMonitor(logger *slog.Logger, agentConfig *config.AgentConfig) *Monitor {
+	realIP, ipErr := network.OutboundIP(agentConfig.GetAddress())
+	if ipErr != nil {
+		logger.Warn("outbound ip is unknown, X-Real-IP is not sent", slog.Any("error", ipErr))
+	}
+
 	return &Monitor{
 		mx:          new(sync.RWMutex),
 		logger:      logger,
-		httpClient:  http.DefaultClient,
+		httpClient:  newHTTPClient(agentConfig),
+		wsClient:    NewWSClient(agentConfig, realIP),
 		agentConfig: agentConfig,
+		realIP:      realIP,
+	}
+}
+
+func newHTTPClient(conf *config.AgentConfig) *http.Client {
+	tlsConfig := conf.GetTLSConfig()
+	if tlsConfig == nil {
+		return http.DefaultClient
 	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}
 }
 
 func (s *Monitor) Run(ctx context.Context) error {
@@ -44,6 +66,11 @@ func (s *Monitor) Run(ctx context.Context) error {
 	s.initRefreshStatsTicker(gCtx, g)
 
 	err := g.Wait()
+
+	if closeErr := s.wsClient.Close(); closeErr != nil {
+		s.logger.WarnContext(ctx, "websocket client close error", slog.Any("error", closeErr))
+	}
+
 	if err != nil {
 		return fmt.Errorf("monitor run error: %w", err)
 	}
@@ -82,9 +109,13 @@ func (s *Monitor) initSendTicker(ctx context.Context, g *errgroup.Group) {
 			select {
 			case <-ticker.C:
 				stats := append(s.getStatForms(), s.getPollCountForm())
-				sendDataErr := sendData(ctx, s.httpClient, s.agentConfig, stats)
+				if s.agentConfig.IsSendMeta() {
+					stats = withMeta(stats)
+				}
+
+				sendDataErr := sendData(ctx, s.httpClient, s.wsClient, s.agentConfig, s.realIP, stats)
 				if sendDataErr != nil {
-					return fmt.Errorf("send stats ticker data error: %w", sendDataErr)
+					s.logger.ErrorContext(ctx, "send stats error", slog.Any("error", sendDataErr))
 				}
 
 				s.resetPollCount()
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/pkg/hashing"
+	"collector/pkg/retry"
+)
+
+const (
+	webhookTimeout      = 10 * time.Second
+	webhookAttempts     = 5
+	webhookInitialDelay = time.Second
+	// webhookQueueLimit bounds the notifications waiting for a webhook, newer ones are dropped beyond it.
+	webhookQueueLimit = 100
+)
+
+type AlertNotification struct {
+	Status domain.AlertState `json:"status"`
+	Alert  domain.Alert      `json:"alert"`
+	SentAt time.Time         `json:"sent_at"`
+}
+
+// WebhookNotifier posts alert state changes to the webhooks of the alert tenant.
+// Payloads are signed with the webhook key the same way the agents sign their requests.
+// Every webhook gets the notifications one at a time in the order they were raised,
+// so a resolution can't overtake the firing it resolves.
+type WebhookNotifier struct {
+	logger   *slog.Logger
+	client   *http.Client
+	webhooks []domain.AlertWebhook
+	key      string
+	queues   map[domain.AlertWebhook]*webhookQueue
+	mx       *sync.Mutex
+}
+
+// webhookQueue holds the notifications pending for a webhook, running is set while they are delivered.
+type webhookQueue struct {
+	pending [][]byte
+	running bool
+}
+
+func NewWebhookNotifier(logger *slog.Logger, conf *config.ServerConfig) *WebhookNotifier {
+	var webhooks []domain.AlertWebhook
+
+	queues := make(map[domain.AlertWebhook]*webhookQueue)
+
+	for _, entry := range conf.GetAlertWebhooks() {
+		webhook, parseErr := domain.ParseAlertWebhook(entry)
+		if parseErr != nil {
+			logger.Error("alert webhook skipped", slog.Any("error", parseErr))
+
+			continue
+		}
+
+		webhooks = append(webhooks, webhook)
+		queues[webhook] = new(webhookQueue)
+	}
+
+	return &WebhookNotifier{
+		logger:   logger,
+		client:   &http.Client{Timeout: webhookTimeout},
+		webhooks: webhooks,
+		key:      conf.GetAlertWebhookKey(),
+		queues:   queues,
+		mx:       new(sync.Mutex),
+	}
+}
+
+// Notify queues the alert for every webhook of its tenant, the deliveries run in the background.
+func (n *WebhookNotifier) Notify(ctx context.Context, alert domain.Alert) {
+	if len(n.webhooks) == 0 {
+		return
+	}
+
+	body, marshErr := json.Marshal(AlertNotification{
+		Status: alert.State,
+		Alert:  alert,
+		SentAt: time.Now(),
+	})
+	if marshErr != nil {
+		n.logger.ErrorContext(ctx, "marshal alert notification error", slog.Any("error", marshErr))
+
+		return
+	}
+
+	for _, webhook := range n.webhooks {
+		if webhook.Tenant == alert.Tenant {
+			n.enqueue(ctx, webhook, body)
+		}
+	}
+}
+
+func (n *WebhookNotifier) enqueue(ctx context.Context, webhook domain.AlertWebhook, body []byte) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	queue := n.queues[webhook]
+
+	if len(queue.pending) >= webhookQueueLimit {
+		n.logger.ErrorContext(
+			ctx,
+			"alert webhook queue is full, notification dropped",
+			slog.String("webhook", webhook.Redacted()),
+		)
+
+		return
+	}
+
+	queue.pending = append(queue.pending, body)
+
+	if !queue.running {
+		queue.running = true
+
+		go n.drain(ctx, webhook, queue)
+	}
+}
+
+// drain delivers the queued notifications of the webhook until none is left.
+func (n *WebhookNotifier) drain(ctx context.Context, webhook domain.AlertWebhook, queue *webhookQueue) {
+	for {
+		n.mx.Lock()
+
+		if len(queue.pending) == 0 {
+			queue.running = false
+			n.mx.Unlock()
+
+			return
+		}
+
+		body := queue.pending[0]
+		queue.pending = queue.pending[1:]
+
+		n.mx.Unlock()
+
+		deliverErr := retry.Backoff(ctx, webhookAttempts, webhookInitialDelay, func() error {
+			return n.deliver(ctx, webhook.URL, body)
+		})
+		if deliverErr != nil {
+			n.logger.ErrorContext(
+				ctx,
+				"deliver alert webhook error",
+				slog.String("webhook", webhook.Redacted()),
+				slog.Any("error", deliverErr),
+			)
+		}
+	}
+}
+
+func (n *WebhookNotifier) deliver(ctx context.Context, target string, body []byte) error {
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if reqErr != nil {
+		return &retry.PermanentError{Err: fmt.Errorf("webhook request error: %w", stripURL(reqErr))}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if n.key != "" {
+		req.Header.Set(domain.HashHeader, hashing.HashByKey(string(body), n.key))
+	}
+
+	resp, respErr := n.client.Do(req)
+	if respErr != nil {
+		return fmt.Errorf("webhook response error: %w", stripURL(respErr))
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusInternalServerError ||
+		resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return &retry.PermanentError{Err: fmt.Errorf("webhook responded with %s", resp.Status)}
+	}
+
+	return nil
+}
+
+// stripURL drops the URL the HTTP client quotes in its errors, it may carry the webhook secret.
+func stripURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+
+	return err
+}
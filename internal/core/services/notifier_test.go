@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/pkg/hashing"
+)
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	const key = "webhook-key"
+
+	firing := domain.Alert{Tenant: domain.DefaultTenant, Rule: "high-alloc", State: domain.AlertStateFiring}
+	resolved := domain.Alert{Tenant: domain.DefaultTenant, Rule: "high-alloc", State: domain.AlertStateResolved}
+	foreign := domain.Alert{Tenant: "team-a", Rule: "high-alloc", State: domain.AlertStateFiring}
+
+	tests := []struct {
+		name     string
+		alerts   []domain.Alert
+		statuses []int
+		want     []domain.AlertState
+	}{
+		{
+			name:     "deliveries keep their order",
+			alerts:   []domain.Alert{firing, resolved},
+			statuses: []int{http.StatusOK, http.StatusOK},
+			want:     []domain.AlertState{domain.AlertStateFiring, domain.AlertStateResolved},
+		},
+		{
+			name:     "failed delivery is retried before the next one",
+			alerts:   []domain.Alert{firing, resolved},
+			statuses: []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusOK},
+			want: []domain.AlertState{
+				domain.AlertStateFiring,
+				domain.AlertStateFiring,
+				domain.AlertStateResolved,
+			},
+		},
+		{
+			name:     "rejected delivery isn't retried",
+			alerts:   []domain.Alert{firing, resolved},
+			statuses: []int{http.StatusBadRequest, http.StatusOK},
+			want:     []domain.AlertState{domain.AlertStateFiring, domain.AlertStateResolved},
+		},
+		{
+			name:     "alerts of another tenant aren't delivered",
+			alerts:   []domain.Alert{foreign, resolved},
+			statuses: []int{http.StatusOK},
+			want:     []domain.AlertState{domain.AlertStateResolved},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				got []domain.AlertState
+				mx  sync.Mutex
+			)
+
+			done := make(chan struct{})
+
+			srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+				body, _ := io.ReadAll(req.Body)
+
+				if !hashing.Verify(string(body), req.Header.Get(domain.HashHeader), key) {
+					t.Errorf("notification signature mismatch")
+				}
+
+				var notification AlertNotification
+				_ = json.Unmarshal(body, &notification)
+
+				// Firing notifications are slow, so a resolution sent concurrently would overtake them.
+				if notification.Status == domain.AlertStateFiring {
+					time.Sleep(50 * time.Millisecond)
+				}
+
+				mx.Lock()
+				defer mx.Unlock()
+
+				got = append(got, notification.Status)
+				writer.WriteHeader(tt.statuses[len(got)-1])
+
+				if len(got) == len(tt.want) {
+					close(done)
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			notifier := NewWebhookNotifier(slog.Default(), &config.ServerConfig{
+				AlertWebhooks:   []string{srv.URL},
+				AlertWebhookKey: key,
+			})
+
+			for _, alert := range tt.alerts {
+				notifier.Notify(context.Background(), alert)
+			}
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("notifications weren't delivered")
+			}
+
+			mx.Lock()
+			defer mx.Unlock()
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("delivered %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
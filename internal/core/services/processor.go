@@ -3,32 +3,29 @@ package services
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"collector/internal/config"
 	"collector/internal/core/domain"
+	"collector/pkg/compression"
+	"collector/pkg/encryption"
 	"collector/pkg/hashing"
+	"collector/pkg/network"
 	"collector/pkg/retry"
 )
 
+const wsSendAttempts = 4
+
 type SendMetricResult struct {
 	Code   int
 	Status string
 	Body   string
 }
 
-func NewSendMetricResult(response *http.Response) *SendMetricResult {
-	body, _ := io.ReadAll(response.Body)
-	return &SendMetricResult{
-		Code:   response.StatusCode,
-		Status: response.Status,
-		Body:   string(body),
-	}
-}
-
 func worker(client *http.Client, jobs <-chan *http.Request, results chan<- *SendMetricResult) {
 	for request := range jobs {
 		var sendMetricResult *SendMetricResult
@@ -42,13 +39,20 @@ func worker(client *http.Client, jobs <-chan *http.Request, results chan<- *Send
 	}
 }
 
+// sendData sends the stats over the configured transport.
+// Over websocket the stats go in a single batch that keeps its ID when sent again,
+// so the server applies it once even if an acknowledgement was lost.
 func sendData(
 	ctx context.Context,
 	client *http.Client,
+	wsClient *WSClient,
 	conf *config.AgentConfig,
+	realIP string,
 	stats []*domain.MetricForm,
 ) error {
-	url := conf.GetAddress()
+	if conf.IsWebSocketTransport() {
+		return sendBatch(ctx, wsClient, stats)
+	}
 
 	poolSize := len(stats)
 
@@ -61,28 +65,9 @@ func sendData(
 	}
 
 	for _, form := range stats {
-		var endpoint string
-		if form.IsGaugeType() {
-			endpoint = fmt.Sprintf("http://%s/update/gauge/%s/%f", url, form.ID, *form.Value)
-		} else if form.IsCounterType() {
-			endpoint = fmt.Sprintf("http://%s/update/counter/%s/%d", url, form.ID, *form.Delta)
-		} else {
-			return fmt.Errorf("invalid metric type: %v", form.MType)
-		}
-
-		data, marshErr := json.Marshal(form)
-		if marshErr != nil {
-			return fmt.Errorf("marshall data error: %w", marshErr)
-		}
-
-		hash := conf.GetHashKey()
-		if hash != "" {
-			hash = hashing.HashByKey(string(data), conf.GetHashKey())
-		}
-
-		req, reqErr := buildRequest(ctx, endpoint, data, hash)
+		req, reqErr := newMetricRequest(ctx, conf, realIP, form)
 		if reqErr != nil {
-			return fmt.Errorf("build request error: %w", reqErr)
+			return reqErr
 		}
 
 		jobs <- req
@@ -93,6 +78,63 @@ func sendData(
 	return nil
 }
 
+func sendBatch(ctx context.Context, wsClient *WSClient, stats []*domain.MetricForm) error {
+	batch, batchErr := wsClient.NewBatch(stats)
+	if batchErr != nil {
+		return batchErr
+	}
+
+	sendErr := retry.Backoff(ctx, wsSendAttempts, time.Second, func() error {
+		return wsClient.Send(ctx, batch)
+	})
+	if sendErr != nil {
+		return fmt.Errorf("send batch %d error: %w", batch.ID, sendErr)
+	}
+
+	return nil
+}
+
+// newMetricRequest builds the request sending a single metric to its update path endpoint.
+func newMetricRequest(
+	ctx context.Context,
+	conf *config.AgentConfig,
+	realIP string,
+	form *domain.MetricForm,
+) (*http.Request, error) {
+	var endpoint string
+	if form.IsGaugeType() {
+		endpoint = fmt.Sprintf(
+			"%s://%s/update/gauge/%s/%f",
+			conf.GetHTTPScheme(),
+			conf.GetAddress(),
+			form.ID,
+			*form.Value,
+		)
+	} else if form.IsCounterType() {
+		endpoint = fmt.Sprintf(
+			"%s://%s/update/counter/%s/%d",
+			conf.GetHTTPScheme(),
+			conf.GetAddress(),
+			form.ID,
+			*form.Delta,
+		)
+	} else {
+		return nil, fmt.Errorf("invalid metric type: %v", form.MType)
+	}
+
+	data, marshErr := conf.GetCodec().MarshalForm(form)
+	if marshErr != nil {
+		return nil, fmt.Errorf("marshall data error: %w", marshErr)
+	}
+
+	req, reqErr := buildRequest(ctx, conf, http.MethodPost, endpoint, realIP, data)
+	if reqErr != nil {
+		return nil, fmt.Errorf("build request error: %w", reqErr)
+	}
+
+	return req, nil
+}
+
 func sendRequest(client *http.Client, req *http.Request) (*SendMetricResult, error) {
 	defer req.Body.Close()
 
@@ -104,35 +146,100 @@ func sendRequest(client *http.Client, req *http.Request) (*SendMetricResult, err
 
 	defer resp.Body.Close()
 
-	_, bodyErr := io.Copy(io.Discard, resp.Body)
+	body, bodyErr := io.ReadAll(resp.Body)
 	if bodyErr != nil {
 		return nil, fmt.Errorf("read body error: %w", bodyErr)
 	}
 
-	return NewSendMetricResult(resp), nil
+	return &SendMetricResult{Code: resp.StatusCode, Status: resp.Status, Body: string(body)}, nil
 }
 
+// buildRequest signs the data and compresses and encrypts the body as configured.
+// Requests without a body are only signed.
 func buildRequest(
 	ctx context.Context,
+	conf *config.AgentConfig,
+	method string,
 	url string,
+	realIP string,
 	data []byte,
-	hash string,
 ) (*http.Request, error) {
+	body := data
+	hasBody := len(data) > 0
+
+	if encoding := conf.GetCompress(); encoding != "" && hasBody {
+		compressed, compressErr := compression.Compress(encoding, body)
+		if compressErr != nil {
+			return nil, fmt.Errorf("compress body error: %w", compressErr)
+		}
+
+		body = compressed
+	}
+
+	if publicKey := conf.GetPublicKey(); publicKey != nil && hasBody {
+		encrypted, encryptErr := encryption.Encrypt(publicKey, body)
+		if encryptErr != nil {
+			return nil, fmt.Errorf("encrypt body error: %w", encryptErr)
+		}
+
+		body = encrypted
+	}
+
 	req, reqErr := http.NewRequestWithContext(
 		ctx,
-		http.MethodPost,
+		method,
 		url,
-		bytes.NewReader(data),
+		bytes.NewReader(body),
 	)
 
 	if reqErr != nil {
 		return nil, fmt.Errorf("request error: %w", reqErr)
 	}
 
-	req.Header.Add("Content-Type", "application/json")
+	if hasBody {
+		if conf.GetPublicKey() != nil {
+			req.Header.Add(domain.EncryptionHeader, encryption.Scheme)
+		}
+
+		req.Header.Add("Content-Type", conf.GetCodec().ContentType())
+
+		if encoding := conf.GetCompress(); encoding != "" {
+			req.Header.Add("Content-Encoding", encoding)
+		}
+	}
+
+	if hashKey := conf.GetHashKey(); hashKey != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		nonce, nonceErr := domain.NewNonce()
+		if nonceErr != nil {
+			return nil, nonceErr
+		}
+
+		req.Header.Add(domain.TimestampHeader, timestamp)
+		req.Header.Add(domain.NonceHeader, nonce)
+		req.Header.Add(
+			domain.HashHeader,
+			hashing.HashByKey(
+				domain.RequestSignaturePayload(req.Method, req.URL.RequestURI(), timestamp, nonce, data),
+				hashKey,
+			),
+		)
+
+		if keyID := conf.GetHashKeyID(); keyID != "" {
+			req.Header.Add(domain.HashKeyIDHeader, keyID)
+		}
+	}
+
+	if tenant := conf.GetTenant(); tenant != "" {
+		req.Header.Add(domain.TenantHeader, tenant)
+	}
+
+	if apiKey := conf.GetAPIKey(); apiKey != "" {
+		req.Header.Add(domain.APIKeyHeader, apiKey)
+	}
 
-	if hash != "" {
-		req.Header.Add(domain.HashHeader, hash)
+	if realIP != "" {
+		req.Header.Add(network.RealIPHeader, realIP)
 	}
 
 	return req, nil
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"collector/internal/adapters/store"
+	"collector/internal/config"
+	"collector/internal/core/domain"
+)
+
+const (
+	anonymousAgent = "anonymous"
+	// maxTrackedAgents bounds the per agent accounting, identities beyond it share the anonymous budget.
+	maxTrackedAgents = 10000
+)
+
+// SeriesGuard admits writes that follow the metric name policy and stay within the series, per agent and tenant limits.
+// Limits apply on series creation only, updates of existing series are always admitted.
+type SeriesGuard struct {
+	st          store.Store
+	policy      *domain.NamePolicy
+	maxSeries   int
+	maxPerAgent int
+	maxTenants  int
+	perAgent    map[string]int
+	mx          *sync.Mutex
+}
+
+func NewSeriesGuard(conf *config.ServerConfig, st store.Store) *SeriesGuard {
+	return &SeriesGuard{
+		st:          st,
+		policy:      conf.GetNamePolicy(),
+		maxSeries:   conf.GetMaxSeries(),
+		maxPerAgent: conf.GetMaxSeriesPerAgent(),
+		maxTenants:  conf.GetMaxTenants(),
+		perAgent:    make(map[string]int),
+		mx:          new(sync.Mutex),
+	}
+}
+
+// Admit checks the forms as a whole and runs apply when all of them may be applied, otherwise none is.
+func (g *SeriesGuard) Admit(ctx context.Context, apply func(), forms ...domain.MetricForm) error {
+	for _, form := range forms {
+		if policyErr := g.policy.Validate(form.ID); policyErr != nil {
+			return policyErr
+		}
+
+		if form.Meta != nil {
+			if metaErr := form.Meta.Validate(); metaErr != nil {
+				return metaErr
+			}
+		}
+	}
+
+	metrics, tenantErr := g.tenantMetrics(ctx)
+	if tenantErr != nil {
+		return tenantErr
+	}
+
+	if (g.maxSeries == 0 && g.maxPerAgent == 0) || len(newSeries(metrics, forms)) == 0 {
+		apply()
+
+		return nil
+	}
+
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	// Series may have been created since the check above, count them again under the lock.
+	created := newSeries(metrics, forms)
+
+	if g.maxSeries > 0 {
+		if total := g.countSeries(); total+len(created) > g.maxSeries {
+			return fmt.Errorf("%w: %d of %d series in use", domain.ErrTooManySeries, total, g.maxSeries)
+		}
+	}
+
+	agent := g.agentOf(ctx)
+
+	if g.maxPerAgent > 0 {
+		if used := g.perAgent[agent]; used+len(created) > g.maxPerAgent {
+			return fmt.Errorf(
+				"%w: %s created %d of %d series",
+				domain.ErrTooManySeries,
+				agent,
+				used,
+				g.maxPerAgent,
+			)
+		}
+	}
+
+	apply()
+
+	g.perAgent[agent] += len(created)
+
+	return nil
+}
+
+// AdmitSnapshot checks the snapshot about to be imported into the tenant of the request.
+// Only the global series limit applies, snapshots aren't attributed to agents.
+// The snapshot is imported with apply once admitted.
+func (g *SeriesGuard) AdmitSnapshot(
+	ctx context.Context,
+	snapshot *domain.Snapshot,
+	replace bool,
+	apply func(),
+) error {
+	if validateErr := snapshot.Validate(g.policy); validateErr != nil {
+		return validateErr
+	}
+
+	metrics, tenantErr := g.tenantMetrics(ctx)
+	if tenantErr != nil {
+		return tenantErr
+	}
+
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	if g.maxSeries == 0 {
+		apply()
+
+		return nil
+	}
+
+	total := g.countSeries()
+	after := total - metrics.Len() + snapshot.Len()
+
+	if !replace {
+		after = total
+
+		for name := range snapshot.Counters {
+			if !metrics.Has(domain.MetricTypeCounter, name) {
+				after++
+			}
+		}
+
+		for name := range snapshot.Gauges {
+			if !metrics.Has(domain.MetricTypeGauge, name) {
+				after++
+			}
+		}
+	}
+
+	if after > g.maxSeries {
+		return fmt.Errorf("%w: %d of %d series after import", domain.ErrTooManySeries, after, g.maxSeries)
+	}
+
+	apply()
+
+	return nil
+}
+
+// tenantMetrics returns the metrics of the request tenant, creating the tenant up to the tenant limit.
+// The tenant comes from the tenant header or the API key, AuthMiddleware rejects a header the key doesn't allow.
+func (g *SeriesGuard) tenantMetrics(ctx context.Context) (*domain.Metrics, error) {
+	tenant := domain.TenantFromContext(ctx)
+
+	if metrics, ok := g.st.LookupMetrics(tenant); ok {
+		return metrics, nil
+	}
+
+	metrics, createErr := g.st.CreateMetrics(tenant, g.maxTenants)
+	if createErr != nil {
+		return nil, createErr
+	}
+
+	return metrics, nil
+}
+
+// agentOf returns the identity the series created by the request are attributed to.
+// Callers must hold the guard lock.
+func (g *SeriesGuard) agentOf(ctx context.Context) string {
+	agent := anonymousAgent
+
+	if agentID, ok := domain.AgentIDFromContext(ctx); ok {
+		agent = "agent:" + agentID
+	} else if key, ok := domain.APIKeyFromContext(ctx); ok {
+		agent = "key:" + key.ID
+	}
+
+	if _, tracked := g.perAgent[agent]; !tracked && len(g.perAgent) >= maxTrackedAgents {
+		return anonymousAgent
+	}
+
+	return agent
+}
+
+// newSeries returns the series the forms would create in the metrics.
+func newSeries(metrics *domain.Metrics, forms []domain.MetricForm) map[string]struct{} {
+	created := make(map[string]struct{})
+
+	for _, form := range forms {
+		if !metrics.Has(form.MType, form.ID) {
+			created[string(form.MType)+"/"+form.ID] = struct{}{}
+		}
+	}
+
+	return created
+}
+
+func (g *SeriesGuard) countSeries() int {
+	total := 0
+
+	for _, tenant := range g.st.GetTenants() {
+		total += g.st.GetMetrics(tenant).Len()
+	}
+
+	return total
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"collector/internal/adapters/store"
+	"collector/internal/config"
+	"collector/internal/core/domain"
+)
+
+func newTestGuard(t *testing.T, conf *config.ServerConfig) (*SeriesGuard, *domain.Metrics) {
+	t.Helper()
+
+	tenants := domain.NewTenants()
+	metrics, _ := tenants.Create(domain.DefaultTenant, 0)
+	metrics.SetGaugeValue("Alloc", 1)
+
+	guard := NewSeriesGuard(conf, store.NewMemoryStorage(tenants))
+
+	policy, policyErr := domain.NewNamePolicy(domain.DefaultMetricNamePattern, domain.DefaultMetricNameMaxLen)
+	if policyErr != nil {
+		t.Fatal(policyErr)
+	}
+
+	guard.policy = policy
+
+	return guard, metrics
+}
+
+func gaugeForm(name string) domain.MetricForm {
+	value := 1.0
+
+	return domain.MetricForm{ID: name, MType: domain.MetricTypeGauge, Value: &value}
+}
+
+func TestSeriesGuard_Admit(t *testing.T) {
+	agent := func(id string) context.Context { return domain.WithAgentID(context.Background(), id) }
+	anonymous := func(client string) context.Context { return domain.WithClientID(context.Background(), client) }
+
+	tests := []struct {
+		name        string
+		conf        *config.ServerConfig
+		first       context.Context
+		second      context.Context
+		series      string
+		wantErr     error
+		wantApplied bool
+	}{
+		{
+			name:        "updates over the global limit",
+			conf:        &config.ServerConfig{MaxSeries: 2},
+			first:       agent("a1"),
+			second:      agent("a2"),
+			series:      "First",
+			wantApplied: true,
+		},
+		{
+			name:    "new series over the global limit",
+			conf:    &config.ServerConfig{MaxSeries: 2},
+			first:   agent("a1"),
+			second:  agent("a2"),
+			series:  "Second",
+			wantErr: domain.ErrTooManySeries,
+		},
+		{
+			name:        "agents have own budgets",
+			conf:        &config.ServerConfig{MaxSeriesPerAgent: 1},
+			first:       agent("a1"),
+			second:      agent("a2"),
+			series:      "Second",
+			wantApplied: true,
+		},
+		{
+			name:    "agent over its budget",
+			conf:    &config.ServerConfig{MaxSeriesPerAgent: 1},
+			first:   agent("a1"),
+			second:  agent("a1"),
+			series:  "Second",
+			wantErr: domain.ErrTooManySeries,
+		},
+		{
+			name:    "client addresses share the anonymous budget",
+			conf:    &config.ServerConfig{MaxSeriesPerAgent: 1},
+			first:   anonymous("ip:192.0.2.1"),
+			second:  anonymous("ip:192.0.2.2"),
+			series:  "Second",
+			wantErr: domain.ErrTooManySeries,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, metrics := newTestGuard(t, tt.conf)
+
+			first := func() { metrics.SetGaugeValue("First", 1) }
+
+			if err := guard.Admit(tt.first, first, gaugeForm("First")); err != nil {
+				t.Fatalf("Admit() first error = %v", err)
+			}
+
+			applied := false
+			second := func() { applied = true }
+
+			if err := guard.Admit(tt.second, second, gaugeForm(tt.series)); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Admit() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if applied != tt.wantApplied {
+				t.Errorf("applied = %v, want %v", applied, tt.wantApplied)
+			}
+		})
+	}
+}
+
+func TestSeriesGuard_AdmitConcurrent(t *testing.T) {
+	const maxSeries = 10
+
+	guard, metrics := newTestGuard(t, &config.ServerConfig{MaxSeries: maxSeries})
+	ctx := domain.WithAgentID(context.Background(), "a1")
+
+	var wg sync.WaitGroup
+
+	for i := range 50 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			form := gaugeForm("Series" + strconv.Itoa(i))
+			_ = guard.Admit(ctx, func() { metrics.SetGaugeValue(form.ID, *form.Value) }, form)
+		}()
+	}
+
+	wg.Wait()
+
+	if got := metrics.Len(); got != maxSeries {
+		t.Errorf("series = %d, want %d", got, maxSeries)
+	}
+}
+
+func TestSeriesGuard_AdmitTenant(t *testing.T) {
+	tests := []struct {
+		name        string
+		conf        *config.ServerConfig
+		wantErr     error
+		wantCreated bool
+	}{
+		{name: "header tenant is created", conf: &config.ServerConfig{}, wantCreated: true},
+		{name: "tenant over the limit", conf: &config.ServerConfig{MaxTenants: 1}, wantErr: domain.ErrTooManyTenants},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, _ := newTestGuard(t, tt.conf)
+			ctx := domain.WithTenant(context.Background(), "team-a")
+
+			if err := guard.Admit(ctx, func() {}, gaugeForm("Alloc")); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Admit() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if _, created := guard.st.LookupMetrics("team-a"); created != tt.wantCreated {
+				t.Errorf("tenant created = %v, want %v", created, tt.wantCreated)
+			}
+		})
+	}
+}
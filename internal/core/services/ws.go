@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/pkg/hashing"
+	"collector/pkg/network"
+	"collector/pkg/retry"
+	"github.com/gorilla/websocket"
+)
+
+const wsExchangeTimeout = 10 * time.Second
+
+var (
+	errUnexpectedAck = errors.New("unexpected batch ack")
+	errBatchRejected = errors.New("batch rejected")
+)
+
+// WSClient keeps a single websocket connection to the server and sends metric batches over it,
+// waiting for the acknowledgement of every batch. The connection is re-established on failure.
+// Batch IDs start from a random value, so IDs of a restarted agent don't collide with the ones
+// the server remembers for deduplication.
+type WSClient struct {
+	conf   *config.AgentConfig
+	dialer *websocket.Dialer
+	conn   *websocket.Conn
+	seq    uint64
+	realIP string
+	mx     *sync.Mutex
+}
+
+func NewWSClient(conf *config.AgentConfig, realIP string) *WSClient {
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = conf.GetTLSConfig()
+
+	return &WSClient{
+		conf:   conf,
+		dialer: &dialer,
+		seq:    rand.Uint64() >> 1,
+		realIP: realIP,
+		mx:     new(sync.Mutex),
+	}
+}
+
+// NewBatch assigns the next ID to the stats, the batch keeps it when sent again after a failure.
+func (c *WSClient) NewBatch(stats []*domain.MetricForm) (*domain.MetricBatch, error) {
+	data, marshErr := json.Marshal(stats)
+	if marshErr != nil {
+		return nil, fmt.Errorf("marshall data error: %w", marshErr)
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.seq++
+
+	return &domain.MetricBatch{ID: c.seq, Metrics: data}, nil
+}
+
+// Send signs the batch and waits for its acknowledgement within wsExchangeTimeout or the context deadline.
+// A batch rejected by the server is returned as a retry.PermanentError, sending it again won't help.
+func (c *WSClient) Send(ctx context.Context, batch *domain.MetricBatch) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if hashKey := c.conf.GetHashKey(); hashKey != "" {
+		nonce, nonceErr := domain.NewNonce()
+		if nonceErr != nil {
+			return nonceErr
+		}
+
+		batch.Timestamp = strconv.FormatInt(time.Now().Unix(), 10)
+		batch.Nonce = nonce
+		batch.Hash = hashing.HashByKey(domain.SignaturePayload(batch.Timestamp, nonce, batch.Metrics), hashKey)
+		batch.KeyID = c.conf.GetHashKeyID()
+	}
+
+	conn, connErr := c.connect(ctx)
+	if connErr != nil {
+		return connErr
+	}
+
+	ack, sendErr := c.exchange(ctx, conn, batch)
+	if sendErr != nil {
+		_ = c.reset()
+
+		return sendErr
+	}
+
+	if ack.Status != domain.BatchStatusOK {
+		return &retry.PermanentError{Err: fmt.Errorf("%w: %d: %s", errBatchRejected, ack.ID, ack.Error)}
+	}
+
+	return nil
+}
+
+func (c *WSClient) Close() error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	_ = c.conn.WriteMessage(websocket.CloseMessage, closeMsg)
+
+	return c.reset()
+}
+
+func (c *WSClient) connect(ctx context.Context) (*websocket.Conn, error) {
+	if c.conn != nil {
+		return c.conn, nil
+	}
+
+	url := fmt.Sprintf("%s://%s/api/v1/ws", c.conf.GetWSScheme(), c.conf.GetAddress())
+
+	header := make(http.Header)
+	if tenant := c.conf.GetTenant(); tenant != "" {
+		header.Set(domain.TenantHeader, tenant)
+	}
+
+	if apiKey := c.conf.GetAPIKey(); apiKey != "" {
+		header.Set(domain.APIKeyHeader, apiKey)
+	}
+
+	if c.realIP != "" {
+		header.Set(network.RealIPHeader, c.realIP)
+	}
+
+	conn, resp, dialErr := c.dialer.DialContext(ctx, url, header)
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+
+	if dialErr != nil {
+		return nil, fmt.Errorf("websocket dial error: %w", dialErr)
+	}
+
+	c.conn = conn
+
+	return conn, nil
+}
+
+// exchange writes the batch and reads its ack, the connection is closed when the context is done.
+func (c *WSClient) exchange(
+	ctx context.Context,
+	conn *websocket.Conn,
+	batch *domain.MetricBatch,
+) (*domain.BatchAck, error) {
+	stop := context.AfterFunc(ctx, func() { _ = conn.NetConn().Close() })
+	defer stop()
+
+	deadline := time.Now().Add(wsExchangeTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	if deadlineErr := conn.SetWriteDeadline(deadline); deadlineErr != nil {
+		return nil, fmt.Errorf("websocket deadline error: %w", deadlineErr)
+	}
+
+	if deadlineErr := conn.SetReadDeadline(deadline); deadlineErr != nil {
+		return nil, fmt.Errorf("websocket deadline error: %w", deadlineErr)
+	}
+
+	if writeErr := conn.WriteJSON(batch); writeErr != nil {
+		return nil, fmt.Errorf("websocket write error: %w", writeErr)
+	}
+
+	var ack domain.BatchAck
+	if readErr := conn.ReadJSON(&ack); readErr != nil {
+		return nil, fmt.Errorf("websocket read error: %w", readErr)
+	}
+
+	if ack.ID != batch.ID {
+		return nil, fmt.Errorf("%w: got %d, want %d", errUnexpectedAck, ack.ID, batch.ID)
+	}
+
+	return &ack, nil
+}
+
+func (c *WSClient) reset() error {
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+
+	if err != nil {
+		return fmt.Errorf("websocket close error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"collector/internal/config"
+	"collector/internal/core/domain"
+	"collector/pkg/retry"
+	"github.com/gorilla/websocket"
+)
+
+// wsTestServer answers every batch with the ack built by reply, a nil ack drops the connection.
+// The returned function lists the IDs of the batches received so far.
+func wsTestServer(
+	t *testing.T,
+	reply func(attempt int, batch domain.MetricBatch) *domain.BatchAck,
+) (*httptest.Server, func() []uint64) {
+	t.Helper()
+
+	var (
+		ids []uint64
+		mx  sync.Mutex
+	)
+
+	upgrader := websocket.Upgrader{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		conn, upgradeErr := upgrader.Upgrade(writer, req, nil)
+		if upgradeErr != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			var batch domain.MetricBatch
+			if readErr := conn.ReadJSON(&batch); readErr != nil {
+				return
+			}
+
+			mx.Lock()
+			ids = append(ids, batch.ID)
+			attempt := len(ids)
+			mx.Unlock()
+
+			ack := reply(attempt, batch)
+			if ack == nil {
+				return
+			}
+
+			if writeErr := conn.WriteJSON(ack); writeErr != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []uint64 {
+		mx.Lock()
+		defer mx.Unlock()
+
+		return slices.Clone(ids)
+	}
+}
+
+func TestSendBatch(t *testing.T) {
+	okAck := func(_ int, batch domain.MetricBatch) *domain.BatchAck {
+		return &domain.BatchAck{ID: batch.ID, Status: domain.BatchStatusOK}
+	}
+
+	tests := []struct {
+		name         string
+		reply        func(attempt int, batch domain.MetricBatch) *domain.BatchAck
+		timeout      time.Duration
+		wantAttempts int
+		wantErr      error
+	}{
+		{name: "acknowledged", reply: okAck, wantAttempts: 1},
+		{
+			name: "resent with the same id after a lost ack",
+			reply: func(attempt int, batch domain.MetricBatch) *domain.BatchAck {
+				if attempt == 1 {
+					return nil
+				}
+
+				return okAck(attempt, batch)
+			},
+			wantAttempts: 2,
+		},
+		{
+			name: "rejected batch isn't resent",
+			reply: func(_ int, batch domain.MetricBatch) *domain.BatchAck {
+				return &domain.BatchAck{ID: batch.ID, Status: domain.BatchStatusError, Error: "invalid"}
+			},
+			wantAttempts: 1,
+			wantErr:      errBatchRejected,
+		},
+		{
+			name: "unanswered batch is abandoned with the context",
+			reply: func(_ int, _ domain.MetricBatch) *domain.BatchAck {
+				time.Sleep(time.Second)
+
+				return nil
+			},
+			timeout:      100 * time.Millisecond,
+			wantAttempts: 1,
+			wantErr:      context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, ids := wsTestServer(t, tt.reply)
+			address := strings.TrimPrefix(srv.URL, "http://")
+			conf := &config.AgentConfig{BaseConfig: config.BaseConfig{Address: address}}
+			client := NewWSClient(conf, "")
+			t.Cleanup(func() { _ = client.Close() })
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				t.Cleanup(cancel)
+			}
+
+			value := 1.0
+			stats := []*domain.MetricForm{{ID: "Alloc", MType: domain.MetricTypeGauge, Value: &value}}
+
+			err := sendBatch(ctx, client, stats)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("sendBatch() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var permanent *retry.PermanentError
+			if errors.Is(tt.wantErr, errBatchRejected) && !errors.As(err, &permanent) {
+				t.Errorf("sendBatch() error = %v, want a permanent error", err)
+			}
+
+			got := ids()
+			if len(got) != tt.wantAttempts {
+				t.Fatalf("server got %d batches, want %d", len(got), tt.wantAttempts)
+			}
+
+			for _, id := range got {
+				if id != got[0] {
+					t.Errorf("batch ids = %v, want the same id on every attempt", got)
+				}
+			}
+		})
+	}
+}
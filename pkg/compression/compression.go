@@ -0,0 +1,212 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	Gzip     = "gzip"
+	Zstd     = "zstd"
+	Brotli   = "br"
+	Identity = "identity"
+
+	// zstdMaxWindow is the largest window a zstd frame may demand from the decoder.
+	zstdMaxWindow = 8 << 20
+)
+
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// preferred orders the supported encodings, the first one wins among equal quality values.
+var preferred = []string{Zstd, Brotli, Gzip}
+
+var (
+	zstdEncoders = sync.Pool{New: func() any {
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+
+		return enc
+	}}
+	zstdDecoders = sync.Pool{New: func() any {
+		dec, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(zstdMaxWindow))
+
+		return dec
+	}}
+)
+
+func IsSupported(encoding string) bool {
+	switch encoding {
+	case Gzip, Zstd, Brotli:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewReader decodes the body compressed with the encoding.
+func NewReader(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case Gzip:
+		gzReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("gzip reader error: %w", err)
+		}
+
+		return gzReader, nil
+	case Zstd:
+		dec := zstdDecoders.Get().(*zstd.Decoder)
+		if err := dec.Reset(r); err != nil {
+			zstdDecoders.Put(dec)
+
+			return nil, fmt.Errorf("zstd reader error: %w", err)
+		}
+
+		return &zstdReader{Decoder: dec}, nil
+	case Brotli:
+		return io.NopCloser(brotli.NewReader(r)), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// NewWriter compresses the data written to w with the encoding, favouring speed over ratio.
+// The writer has to be closed to flush the trailing data.
+func NewWriter(encoding string, w io.Writer) (Writer, error) {
+	switch encoding {
+	case Gzip:
+		gzWriter, err := gzip.NewWriterLevel(w, gzip.BestSpeed)
+		if err != nil {
+			return nil, fmt.Errorf("gzip writer error: %w", err)
+		}
+
+		return gzWriter, nil
+	case Zstd:
+		enc := zstdEncoders.Get().(*zstd.Encoder)
+		enc.Reset(w)
+
+		return &zstdWriter{Encoder: enc}, nil
+	case Brotli:
+		return brotli.NewWriterLevel(w, brotli.BestSpeed), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// Writer is a compressing writer able to flush the data buffered so far.
+type Writer interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// Compress returns the data compressed with the encoding.
+func Compress(encoding string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer, err := NewWriter(encoding, &buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, writeErr := writer.Write(data); writeErr != nil {
+		return nil, fmt.Errorf("compress %s error: %w", encoding, writeErr)
+	}
+
+	if closeErr := writer.Close(); closeErr != nil {
+		return nil, fmt.Errorf("compress %s close error: %w", encoding, closeErr)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Negotiate picks the supported encoding with the highest quality value in the Accept-Encoding header,
+// an empty string when the client accepts none of them.
+func Negotiate(acceptEncoding string) string {
+	qualities := make(map[string]float64)
+	wildcard := -1.0
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+
+		q := 1.0
+		if rawQ, hasQ := strings.CutPrefix(strings.TrimSpace(params), "q="); hasQ {
+			parsedQ, convErr := strconv.ParseFloat(rawQ, 64)
+			if convErr != nil {
+				continue
+			}
+
+			q = parsedQ
+		}
+
+		if coding == "*" {
+			wildcard = q
+		} else if coding != "" {
+			qualities[coding] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+
+	for _, encoding := range preferred {
+		q, listed := qualities[encoding]
+		if !listed {
+			q = wildcard
+		}
+
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+
+	return best
+}
+
+// zstdReader returns the decoder to the pool on close.
+type zstdReader struct {
+	*zstd.Decoder
+}
+
+func (r *zstdReader) Close() error {
+	if r.Decoder == nil {
+		return nil
+	}
+
+	if err := r.Decoder.Reset(nil); err != nil {
+		return fmt.Errorf("zstd reader reset error: %w", err)
+	}
+
+	zstdDecoders.Put(r.Decoder)
+	r.Decoder = nil
+
+	return nil
+}
+
+// zstdWriter returns the encoder to the pool on close.
+type zstdWriter struct {
+	*zstd.Encoder
+}
+
+func (w *zstdWriter) Close() error {
+	if w.Encoder == nil {
+		return nil
+	}
+
+	err := w.Encoder.Close()
+	w.Encoder.Reset(nil)
+	zstdEncoders.Put(w.Encoder)
+	w.Encoder = nil
+
+	if err != nil {
+		return fmt.Errorf("zstd writer close error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package compression
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestCompress_RoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"id":"Alloc","type":"gauge","value":1.5}`), 64)
+
+	tests := []struct {
+		name     string
+		encoding string
+	}{
+		{name: "gzip", encoding: Gzip},
+		{name: "zstd", encoding: Zstd},
+		{name: "brotli", encoding: Brotli},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, compressErr := Compress(tt.encoding, data)
+			if compressErr != nil {
+				t.Fatalf("Compress() error = %v", compressErr)
+			}
+
+			reader, readerErr := NewReader(tt.encoding, bytes.NewReader(compressed))
+			if readerErr != nil {
+				t.Fatalf("NewReader() error = %v", readerErr)
+			}
+			defer reader.Close()
+
+			got, readErr := io.ReadAll(reader)
+			if readErr != nil || !bytes.Equal(got, data) {
+				t.Errorf("NewReader() read %d bytes, error = %v, want %d bytes", len(got), readErr, len(data))
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		want           string
+	}{
+		{name: "no header", acceptEncoding: "", want: ""},
+		{name: "gzip only", acceptEncoding: "gzip", want: Gzip},
+		{name: "server preference on a tie", acceptEncoding: "gzip, br, zstd", want: Zstd},
+		{name: "quality values", acceptEncoding: "zstd;q=0.5, br;q=0.8, gzip;q=0.1", want: Brotli},
+		{name: "refused encoding", acceptEncoding: "zstd;q=0, gzip", want: Gzip},
+		{name: "wildcard", acceptEncoding: "*;q=0.3, zstd;q=0", want: Brotli},
+		{name: "unsupported only", acceptEncoding: "deflate, compress", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Negotiate(tt.acceptEncoding); got != tt.want {
+				t.Errorf("Negotiate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+DELETE FROM counters WHERE tenant <> 'default';
+ALTER TABLE counters
+    DROP CONSTRAINT IF EXISTS counters_pkey;
+ALTER TABLE counters
+    DROP COLUMN IF EXISTS tenant;
+ALTER TABLE counters
+    ADD PRIMARY KEY (name);
+
+DELETE FROM gauges WHERE tenant <> 'default';
+ALTER TABLE gauges
+    DROP CONSTRAINT IF EXISTS gauges_pkey;
+ALTER TABLE gauges
+    DROP COLUMN IF EXISTS tenant;
+ALTER TABLE gauges
+    ADD PRIMARY KEY (name);
@@ -0,0 +1,13 @@
+ALTER TABLE counters
+    ADD COLUMN IF NOT EXISTS tenant VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE counters
+    DROP CONSTRAINT IF EXISTS counters_pkey;
+ALTER TABLE counters
+    ADD PRIMARY KEY (tenant, name);
+
+ALTER TABLE gauges
+    ADD COLUMN IF NOT EXISTS tenant VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE gauges
+    DROP CONSTRAINT IF EXISTS gauges_pkey;
+ALTER TABLE gauges
+    ADD PRIMARY KEY (tenant, name);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id         VARCHAR(32) PRIMARY KEY,
+    hash       CHAR(64)    NOT NULL UNIQUE,
+    scopes     TEXT[]      NOT NULL,
+    tenant     VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP   NOT NULL
+);
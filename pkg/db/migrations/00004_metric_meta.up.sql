@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS metric_meta
+(
+    tenant VARCHAR(64)   NOT NULL DEFAULT 'default',
+    type   VARCHAR(16)   NOT NULL,
+    name   VARCHAR(255)  NOT NULL,
+    unit   VARCHAR(32)   NOT NULL DEFAULT '',
+    help   VARCHAR(1024) NOT NULL DEFAULT '',
+    owner  VARCHAR(64)   NOT NULL DEFAULT '',
+    PRIMARY KEY (tenant, type, name)
+);
@@ -0,0 +1,181 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Scheme names the hybrid scheme: a random AES-256-GCM key encrypts the payload
+// and is itself wrapped with RSA-OAEP (SHA-256) for the recipient.
+const Scheme = "rsa-oaep-aes256gcm"
+
+const (
+	aesKeySize    = 32
+	keyLengthSize = 2
+)
+
+var (
+	ErrMalformedMessage = errors.New("malformed encrypted message")
+	errNoPEMBlock       = errors.New("no PEM block found")
+	errNotRSAKey        = errors.New("not an RSA key")
+)
+
+// Encrypt seals plain for the owner of pub.
+// The message layout is: wrapped key length (2 bytes) | wrapped key | GCM nonce | ciphertext.
+func Encrypt(pub *rsa.PublicKey, plain []byte) ([]byte, error) {
+	key := make([]byte, aesKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate session key error: %w", err)
+	}
+
+	wrappedKey, wrapErr := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, key, nil)
+	if wrapErr != nil {
+		return nil, fmt.Errorf("wrap session key error: %w", wrapErr)
+	}
+
+	gcm, gcmErr := newGCM(key)
+	if gcmErr != nil {
+		return nil, gcmErr
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce error: %w", err)
+	}
+
+	msg := make([]byte, keyLengthSize, keyLengthSize+len(wrappedKey)+len(nonce)+len(plain)+gcm.Overhead())
+	binary.BigEndian.PutUint16(msg, uint16(len(wrappedKey)))
+	msg = append(msg, wrappedKey...)
+	msg = append(msg, nonce...)
+
+	return gcm.Seal(msg, nonce, plain, nil), nil
+}
+
+// Decrypt opens a message produced by Encrypt.
+func Decrypt(priv *rsa.PrivateKey, msg []byte) ([]byte, error) {
+	if len(msg) < keyLengthSize {
+		return nil, ErrMalformedMessage
+	}
+
+	keyLen := int(binary.BigEndian.Uint16(msg))
+	msg = msg[keyLengthSize:]
+
+	if len(msg) < keyLen {
+		return nil, ErrMalformedMessage
+	}
+
+	key, unwrapErr := rsa.DecryptOAEP(sha256.New(), nil, priv, msg[:keyLen], nil)
+	if unwrapErr != nil {
+		return nil, fmt.Errorf("unwrap session key error: %w", unwrapErr)
+	}
+
+	gcm, gcmErr := newGCM(key)
+	if gcmErr != nil {
+		return nil, gcmErr
+	}
+
+	msg = msg[keyLen:]
+	if len(msg) < gcm.NonceSize() {
+		return nil, ErrMalformedMessage
+	}
+
+	plain, openErr := gcm.Open(nil, msg[:gcm.NonceSize()], msg[gcm.NonceSize():], nil)
+	if openErr != nil {
+		return nil, fmt.Errorf("decrypt payload error: %w", openErr)
+	}
+
+	return plain, nil
+}
+
+func LoadPublicKey(path string) (*rsa.PublicKey, error) {
+	block, readErr := readPEM(path)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		key, parseErr := x509.ParsePKCS1PublicKey(block.Bytes)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse public key error: %w", parseErr)
+		}
+
+		return key, nil
+	}
+
+	key, parseErr := x509.ParsePKIXPublicKey(block.Bytes)
+	if parseErr != nil {
+		return nil, fmt.Errorf("parse public key error: %w", parseErr)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errNotRSAKey
+	}
+
+	return rsaKey, nil
+}
+
+func LoadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	block, readErr := readPEM(path)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		key, parseErr := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse private key error: %w", parseErr)
+		}
+
+		return key, nil
+	}
+
+	key, parseErr := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if parseErr != nil {
+		return nil, fmt.Errorf("parse private key error: %w", parseErr)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errNotRSAKey
+	}
+
+	return rsaKey, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, fmt.Errorf("read key file error: %w", readErr)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: %w", path, errNoPEMBlock)
+	}
+
+	return block, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, blockErr := aes.NewCipher(key)
+	if blockErr != nil {
+		return nil, fmt.Errorf("create cipher error: %w", blockErr)
+	}
+
+	gcm, gcmErr := cipher.NewGCM(block)
+	if gcmErr != nil {
+		return nil, fmt.Errorf("create gcm error: %w", gcmErr)
+	}
+
+	return gcm, nil
+}
@@ -0,0 +1,51 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	priv, genErr := rsa.GenerateKey(rand.Reader, 2048)
+	if genErr != nil {
+		t.Fatalf("GenerateKey() error = %v", genErr)
+	}
+
+	other, genErr := rsa.GenerateKey(rand.Reader, 2048)
+	if genErr != nil {
+		t.Fatalf("GenerateKey() error = %v", genErr)
+	}
+
+	plain := []byte(`{"id":"PollCount","type":"counter","delta":1}`)
+
+	msg, encErr := Encrypt(&priv.PublicKey, plain)
+	if encErr != nil {
+		t.Fatalf("Encrypt() error = %v", encErr)
+	}
+
+	tests := []struct {
+		name    string
+		key     *rsa.PrivateKey
+		msg     []byte
+		wantErr bool
+	}{
+		{name: "matching key", key: priv, msg: msg},
+		{name: "foreign key", key: other, msg: msg, wantErr: true},
+		{name: "truncated message", key: priv, msg: msg[:len(msg)-1], wantErr: true},
+		{name: "empty message", key: priv, msg: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decrypt(tt.key, tt.msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decrypt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !bytes.Equal(got, plain) {
+				t.Errorf("Decrypt() = %s, want %s", got, plain)
+			}
+		})
+	}
+}
@@ -12,3 +12,17 @@ func HashByKey(data string, key string) string {
 
 	return hex.EncodeToString(h.Sum(nil))
 }
+
+// Verify reports whether sign is the hex encoded HMAC of data by key.
+// The comparison runs in constant time.
+func Verify(data string, sign string, key string) bool {
+	decoded, decodeErr := hex.DecodeString(sign)
+	if decodeErr != nil {
+		return false
+	}
+
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(data))
+
+	return hmac.Equal(decoded, h.Sum(nil))
+}
@@ -0,0 +1,91 @@
+package hashing
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownPrimaryKey = errors.New("primary key id is not in the key set")
+
+// KeySet holds the active signing keys by id.
+// Requests may be signed with any of them, responses are signed with the primary one.
+type KeySet struct {
+	keys    map[string]string
+	primary string
+}
+
+func NewKeySet(primary string, keys map[string]string) (*KeySet, error) {
+	if len(keys) > 0 {
+		if _, ok := keys[primary]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownPrimaryKey, primary)
+		}
+	}
+
+	return &KeySet{keys: keys, primary: primary}, nil
+}
+
+// ParseKeys parses a comma-separated list of id:key pairs.
+func ParseKeys(raw string) (map[string]string, error) {
+	keys := make(map[string]string)
+
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		id, key, found := strings.Cut(pair, ":")
+		if !found || id == "" || key == "" {
+			return nil, fmt.Errorf("invalid key pair: %q", pair)
+		}
+
+		if _, exists := keys[id]; exists {
+			return nil, fmt.Errorf("duplicate key id: %q", id)
+		}
+
+		keys[id] = key
+	}
+
+	return keys, nil
+}
+
+func (ks *KeySet) IsEmpty() bool {
+	return ks == nil || len(ks.keys) == 0
+}
+
+// IDs returns the sorted ids of the active keys.
+func (ks *KeySet) IDs() []string {
+	ids := make([]string, 0, len(ks.keys))
+	for id := range ks.keys {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+// Sign signs data with the primary key and returns the key id along with the signature.
+func (ks *KeySet) Sign(data string) (string, string) {
+	return ks.primary, HashByKey(data, ks.keys[ks.primary])
+}
+
+// Verify checks sign against the key with the given id.
+// Signatures without a key id are checked against every active key.
+func (ks *KeySet) Verify(data string, sign string, id string) bool {
+	if id != "" {
+		key, ok := ks.keys[id]
+
+		return ok && Verify(data, sign, key)
+	}
+
+	for _, key := range ks.keys {
+		if Verify(data, sign, key) {
+			return true
+		}
+	}
+
+	return false
+}
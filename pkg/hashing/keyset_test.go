@@ -0,0 +1,60 @@
+package hashing
+
+import (
+	"testing"
+)
+
+func TestKeySet_Verify(t *testing.T) {
+	keys, parseErr := ParseKeys("old:first, new:second")
+	if parseErr != nil {
+		t.Fatalf("ParseKeys() error = %v", parseErr)
+	}
+
+	ks, ksErr := NewKeySet("new", keys)
+	if ksErr != nil {
+		t.Fatalf("NewKeySet() error = %v", ksErr)
+	}
+
+	type args struct {
+		sign string
+		id   string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "primary key",
+			args: args{sign: HashByKey("data", "second"), id: "new"},
+			want: true,
+		},
+		{
+			name: "rotated out key still active",
+			args: args{sign: HashByKey("data", "first"), id: "old"},
+			want: true,
+		},
+		{
+			name: "legacy signature without id",
+			args: args{sign: HashByKey("data", "first")},
+			want: true,
+		},
+		{
+			name: "key id mismatch",
+			args: args{sign: HashByKey("data", "first"), id: "new"},
+			want: false,
+		},
+		{
+			name: "unknown key id",
+			args: args{sign: HashByKey("data", "first"), id: "gone"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ks.Verify("data", tt.args.sign, tt.args.id); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
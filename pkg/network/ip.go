@@ -0,0 +1,59 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+)
+
+const RealIPHeader = "X-Real-IP"
+
+// ClientIP returns the connection peer address. The X-Real-IP header is only honored
+// when the peer is one of the trusted proxies, any client could set it otherwise.
+func ClientIP(req *http.Request, proxies []netip.Prefix) (netip.Addr, error) {
+	addrPort, parseErr := netip.ParseAddrPort(req.RemoteAddr)
+	if parseErr != nil {
+		return netip.Addr{}, fmt.Errorf("parse remote addr error: %w", parseErr)
+	}
+
+	peer := addrPort.Addr().Unmap()
+
+	realIP := req.Header.Get(RealIPHeader)
+	if realIP == "" || !ContainsIP(proxies, peer) {
+		return peer, nil
+	}
+
+	addr, parseErr := netip.ParseAddr(realIP)
+	if parseErr != nil {
+		return netip.Addr{}, fmt.Errorf("parse %s error: %w", RealIPHeader, parseErr)
+	}
+
+	return addr.Unmap(), nil
+}
+
+// ContainsIP reports whether the address belongs to one of the subnets.
+func ContainsIP(subnets []netip.Prefix, ip netip.Addr) bool {
+	return slices.ContainsFunc(subnets, func(subnet netip.Prefix) bool {
+		return subnet.Contains(ip)
+	})
+}
+
+// OutboundIP returns the local address used to reach the server.
+// Dialing UDP sends no packets, it only resolves the route.
+func OutboundIP(address string) (string, error) {
+	conn, dialErr := net.Dial("udp", address)
+	if dialErr != nil {
+		return "", fmt.Errorf("resolve outbound ip error: %w", dialErr)
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return "", fmt.Errorf("unexpected local addr: %s", conn.LocalAddr())
+	}
+
+	return addr.IP.String(), nil
+}
@@ -2,6 +2,7 @@ package network
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"net/http"
 )
@@ -30,3 +31,10 @@ func NewRequestInfo(req *http.Request) *RequestInfo {
 func (i *RequestInfo) String() string {
 	return i.Method + " " + i.URL + " " + i.Body
 }
+
+// IsBodyTooLarge reports whether err comes from a body exceeding its http.MaxBytesReader limit.
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+
+	return errors.As(err, &maxBytesErr)
+}
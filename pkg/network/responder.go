@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"collector/internal/config"
 	"collector/internal/core/domain"
-	"collector/pkg/hashing"
 )
 
 type Response struct {
@@ -23,14 +25,50 @@ func NewResponse(logger *slog.Logger, conf *config.ServerConfig) *Response {
 	}
 }
 
+// Success answers with an empty body, signed so clients can tell it wasn't forged.
 func (resp *Response) Success(writer http.ResponseWriter) {
 	resp.setDefaultHeaders(writer)
+	resp.sign(writer, nil)
 }
 
 func (resp *Response) BadRequestError(writer http.ResponseWriter, e string) {
 	http.Error(writer, e, http.StatusBadRequest)
 }
 
+// DecodeError reports a body that couldn't be read: 413 when it exceeds the size limit, 400 otherwise.
+func (resp *Response) DecodeError(writer http.ResponseWriter, err error) {
+	if IsBodyTooLarge(err) {
+		resp.PayloadTooLarge(writer)
+
+		return
+	}
+
+	resp.BadRequestError(writer, err.Error())
+}
+
+func (resp *Response) PayloadTooLarge(writer http.ResponseWriter) {
+	http.Error(
+		writer,
+		http.StatusText(http.StatusRequestEntityTooLarge),
+		http.StatusRequestEntityTooLarge,
+	)
+}
+
+func (resp *Response) Unauthorized(writer http.ResponseWriter, e string) {
+	http.Error(writer, e, http.StatusUnauthorized)
+}
+
+func (resp *Response) Forbidden(writer http.ResponseWriter, e string) {
+	http.Error(writer, e, http.StatusForbidden)
+}
+
+// TooManyRequests rejects a rate limited request, retryAfter is rounded up to whole seconds.
+func (resp *Response) TooManyRequests(writer http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	writer.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	http.Error(writer, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
+
 func (resp *Response) ServerError(writer http.ResponseWriter, e string) {
 	http.Error(writer, e, http.StatusInternalServerError)
 }
@@ -41,27 +79,43 @@ func (resp *Response) Send(
 	statusCode int,
 	data any,
 ) {
-	resp.setDefaultHeaders(writer)
-	resp.setStatusCode(writer, statusCode)
-
 	marshData, err := json.Marshal(data)
 	if err != nil {
+		resp.setDefaultHeaders(writer)
+		resp.setStatusCode(writer, http.StatusInternalServerError)
 		resp.logger.ErrorContext(ctx, "error encoding response", slog.Any("error", err))
 
 		return
 	}
 
-	hashKey := resp.conf.GetHashKey()
-	if hashKey != "" {
-		hashBody := hashing.HashByKey(string(marshData), hashKey)
-		writer.Header().Add(domain.HashHeader, hashBody)
-	}
+	resp.SendBody(ctx, writer, statusCode, domain.ContentTypeJSON, marshData)
+}
 
-	_, err = writer.Write(marshData)
-	if err != nil {
+// SendBody writes the already encoded body signing it the same way Send does.
+func (resp *Response) SendBody(
+	ctx context.Context,
+	writer http.ResponseWriter,
+	statusCode int,
+	contentType string,
+	body []byte,
+) {
+	writer.Header().Add("Content-Type", contentType)
+	resp.sign(writer, body)
+	resp.setStatusCode(writer, statusCode)
+
+	if _, err := writer.Write(body); err != nil {
 		resp.logger.ErrorContext(ctx, "write response error", slog.Any("error", err))
+	}
+}
 
-		return
+func (resp *Response) sign(writer http.ResponseWriter, body []byte) {
+	if keySet := resp.conf.GetKeySet(); !keySet.IsEmpty() {
+		keyID, hashBody := keySet.Sign(string(body))
+		writer.Header().Add(domain.HashHeader, hashBody)
+
+		if keyID != "" {
+			writer.Header().Add(domain.HashKeyIDHeader, keyID)
+		}
 	}
 }
 
@@ -70,5 +124,5 @@ func (resp *Response) setStatusCode(writer http.ResponseWriter, statusCode int)
 }
 
 func (resp *Response) setDefaultHeaders(writer http.ResponseWriter) {
-	writer.Header().Add("Content-Type", "application/json")
+	writer.Header().Add("Content-Type", domain.ContentTypeJSON)
 }
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const pruneInterval = time.Minute
+
+// Limiter is a set of token buckets keyed by client.
+// Each bucket refills at rate tokens per second up to burst.
+type Limiter struct {
+	buckets   map[string]*bucket
+	rate      float64
+	burst     float64
+	lastPrune time.Time
+	mx        *sync.Mutex
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New returns a limiter or nil when rate is not positive, a nil limiter allows everything.
+func New(rate int, burst int) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+
+	if burst < rate {
+		burst = rate
+	}
+
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		rate:    float64(rate),
+		burst:   float64(burst),
+		mx:      new(sync.Mutex),
+	}
+}
+
+// Allow takes a token from the key bucket.
+// When the bucket is empty it returns false and the time until the next token.
+func (l *Limiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if now.Sub(l.lastPrune) >= pruneInterval {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+
+		return false, wait
+	}
+
+	b.tokens--
+
+	return true, 0
+}
+
+// Refund returns the token taken by Allow for a request that was rejected later on.
+func (l *Limiter) Refund(key string) {
+	if l == nil {
+		return
+	}
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if b, ok := l.buckets[key]; ok {
+		b.tokens = math.Min(l.burst, b.tokens+1)
+	}
+}
+
+// prune drops the buckets that have refilled completely, they are equal to new ones.
+func (l *Limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.lastPrune = now
+}
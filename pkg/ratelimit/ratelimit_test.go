@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	type call struct {
+		key       string
+		at        time.Duration
+		want      bool
+		wantAfter time.Duration
+	}
+	tests := []struct {
+		name  string
+		rate  int
+		burst int
+		calls []call
+	}{
+		{
+			name:  "burst then wait",
+			rate:  2,
+			burst: 2,
+			calls: []call{
+				{key: "a", want: true},
+				{key: "a", want: true},
+				{key: "a", want: false, wantAfter: 500 * time.Millisecond},
+				{key: "a", at: 500 * time.Millisecond, want: true},
+			},
+		},
+		{
+			name: "keys are independent",
+			rate: 1,
+			calls: []call{
+				{key: "a", want: true},
+				{key: "a", want: false, wantAfter: time.Second},
+				{key: "b", want: true},
+			},
+		},
+		{
+			name: "disabled",
+			calls: []call{
+				{key: "a", want: true},
+				{key: "a", want: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := New(tt.rate, tt.burst)
+
+			for i, c := range tt.calls {
+				got, after := limiter.Allow(c.key, now.Add(c.at))
+				if got != c.want || after != c.wantAfter {
+					t.Errorf("call %d: Allow() = %v, %v, want %v, %v", i, got, after, c.want, c.wantAfter)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package retry
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
 
 func Try(fn func() error) error {
 	durations := [3]int{1, 3, 5}
@@ -16,3 +21,40 @@ func Try(fn func() error) error {
 
 	return err
 }
+
+// PermanentError stops Backoff from retrying the wrapped error.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Backoff calls fn up to attempts times, doubling the delay after every failure starting from initial.
+func Backoff(ctx context.Context, attempts int, initial time.Duration, fn func() error) error {
+	delay := initial
+
+	var err error
+
+	for try := 1; ; try++ {
+		err = fn()
+
+		var permanent *PermanentError
+		if err == nil || errors.As(err, &permanent) || try >= attempts {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("backoff interrupted: %w", errors.Join(err, ctx.Err()))
+		}
+
+		delay *= 2
+	}
+}
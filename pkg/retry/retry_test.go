@@ -0,0 +1,81 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	errTemporary := errors.New("temporary")
+	errFatal := errors.New("fatal")
+
+	tests := []struct {
+		name      string
+		failures  int
+		failWith  error
+		attempts  int
+		cancel    bool
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "first call succeeds", attempts: 3, wantCalls: 1},
+		{name: "succeeds after failures", failures: 2, failWith: errTemporary, attempts: 3, wantCalls: 3},
+		{
+			name:      "attempts exhausted",
+			failures:  5,
+			failWith:  errTemporary,
+			attempts:  3,
+			wantCalls: 3,
+			wantErr:   errTemporary,
+		},
+		{
+			name:      "permanent error stops retries",
+			failures:  5,
+			failWith:  &PermanentError{Err: errFatal},
+			attempts:  3,
+			wantCalls: 1,
+			wantErr:   errFatal,
+		},
+		{
+			name:      "canceled context stops retries",
+			failures:  5,
+			failWith:  errTemporary,
+			attempts:  3,
+			cancel:    true,
+			wantCalls: 1,
+			wantErr:   context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			calls := 0
+
+			err := Backoff(ctx, tt.attempts, time.Millisecond, func() error {
+				calls++
+
+				if tt.cancel {
+					cancel()
+				}
+
+				if calls <= tt.failures {
+					return tt.failWith
+				}
+
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Backoff() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("Backoff() calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
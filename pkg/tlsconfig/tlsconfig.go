@@ -0,0 +1,100 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var errNoCertificates = errors.New("no certificates found")
+
+// ParseVersion converts "1.2" or "1.3" to the crypto/tls version constant.
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported tls version: %q", version)
+	}
+}
+
+// NewServer builds the listener config.
+// When clientCAFile is set clients must present a certificate signed by that CA.
+func NewServer(
+	certFile string,
+	keyFile string,
+	minVersion uint16,
+	clientCAFile string,
+) (*tls.Config, error) {
+	cert, certErr := tls.LoadX509KeyPair(certFile, keyFile)
+	if certErr != nil {
+		return nil, fmt.Errorf("load server certificate error: %w", certErr)
+	}
+
+	conf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   minVersion,
+	}
+
+	if clientCAFile != "" {
+		pool, poolErr := LoadCertPool(clientCAFile)
+		if poolErr != nil {
+			return nil, poolErr
+		}
+
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return conf, nil
+}
+
+// NewClient builds the agent config.
+// A CA file pins the server to that CA instead of the system roots,
+// a certificate pair is presented to servers requiring mutual TLS.
+func NewClient(
+	caFile string,
+	certFile string,
+	keyFile string,
+	minVersion uint16,
+) (*tls.Config, error) {
+	conf := &tls.Config{MinVersion: minVersion}
+
+	if caFile != "" {
+		pool, poolErr := LoadCertPool(caFile)
+		if poolErr != nil {
+			return nil, poolErr
+		}
+
+		conf.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, certErr := tls.LoadX509KeyPair(certFile, keyFile)
+		if certErr != nil {
+			return nil, fmt.Errorf("load client certificate error: %w", certErr)
+		}
+
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return conf, nil
+}
+
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, fmt.Errorf("read ca file error: %w", readErr)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s: %w", path, errNoCertificates)
+	}
+
+	return pool, nil
+}
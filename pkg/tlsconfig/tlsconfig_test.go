@@ -0,0 +1,88 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"collector/pkg/tlsconfig/tlstest"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    uint16
+		wantErr bool
+	}{
+		{version: "1.2", want: tls.VersionTLS12},
+		{version: "1.3", want: tls.VersionTLS13},
+		{version: "1.1", wantErr: true},
+		{version: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := ParseVersion(tt.version)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseVersion() = %v, %v, want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	serverCA := tlstest.NewCA(t, "server-ca")
+	otherCA := tlstest.NewCA(t, "other-ca")
+	clientCA := tlstest.NewCA(t, "client-ca")
+	serverCert, serverKey := serverCA.Issue(t, "server")
+	clientCert, clientKey := clientCA.Issue(t, "agent-1")
+
+	tests := []struct {
+		name         string
+		caFile       string
+		certFile     string
+		keyFile      string
+		clientCAFile string
+		wantErr      bool
+	}{
+		{name: "server pinned to its ca", caFile: serverCA.File},
+		{name: "server pinned to another ca", caFile: otherCA.File, wantErr: true},
+		{
+			name:         "client certificate presented",
+			caFile:       serverCA.File,
+			certFile:     clientCert,
+			keyFile:      clientKey,
+			clientCAFile: clientCA.File,
+		},
+		{name: "client certificate missing", caFile: serverCA.File, clientCAFile: clientCA.File, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverConf, serverErr := NewServer(serverCert, serverKey, tls.VersionTLS12, tt.clientCAFile)
+			if serverErr != nil {
+				t.Fatal(serverErr)
+			}
+
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+			srv.TLS = serverConf
+			srv.StartTLS()
+			t.Cleanup(srv.Close)
+
+			clientConf, clientErr := NewClient(tt.caFile, tt.certFile, tt.keyFile, tls.VersionTLS12)
+			if clientErr != nil {
+				t.Fatal(clientErr)
+			}
+
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConf}}
+
+			resp, err := client.Get(srv.URL)
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("request error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Package tlstest issues throwaway certificates for the TLS tests.
+package tlstest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// CA is a certificate authority whose certificate is written to File.
+type CA struct {
+	File string
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	dir  string
+}
+
+func NewCA(t *testing.T, name string) *CA {
+	t.Helper()
+
+	key := newKey(t)
+	template := newTemplate(name)
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign
+
+	der, createErr := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if createErr != nil {
+		t.Fatal(createErr)
+	}
+
+	cert, parseErr := x509.ParseCertificate(der)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+
+	dir := t.TempDir()
+	ca := &CA{File: filepath.Join(dir, name+".crt"), cert: cert, key: key, dir: dir}
+	writePEM(t, ca.File, "CERTIFICATE", der)
+
+	return ca
+}
+
+// Issue signs a certificate for the common name valid for localhost and returns its certificate and key files.
+func (ca *CA) Issue(t *testing.T, commonName string) (string, string) {
+	t.Helper()
+
+	key := newKey(t)
+	template := newTemplate(commonName)
+	template.DNSNames = []string{"localhost"}
+	template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1)}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+
+	der, createErr := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if createErr != nil {
+		t.Fatal(createErr)
+	}
+
+	keyDER, marshErr := x509.MarshalPKCS8PrivateKey(key)
+	if marshErr != nil {
+		t.Fatal(marshErr)
+	}
+
+	certFile := filepath.Join(ca.dir, commonName+".crt")
+	keyFile := filepath.Join(ca.dir, commonName+".key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "PRIVATE KEY", keyDER)
+
+	return certFile, keyFile
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, keyErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if keyErr != nil {
+		t.Fatal(keyErr)
+	}
+
+	return key
+}
+
+func newTemplate(commonName string) *x509.Certificate {
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}